@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"broken-order-service/internal/modal"
+	"broken-order-service/internal/workflows"
+
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+)
+
+// decideTask sends a task decision as a workflow update and waits for the result.
+// On failure it also returns the HTTP status to report: validation errors from the workflow map to
+// 422 (bad or unknown task) or 409 (task already decided, or a repeat approval).
+func decideTask(tc client.Client, workflowID, runID string, d modal.TaskDecision) (modal.DecisionResult, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var res modal.DecisionResult
+	h, err := tc.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   workflowID,
+		RunID:        runID,
+		UpdateName:   workflows.TaskDecisionUpdate,
+		Args:         []any{d},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err == nil {
+		err = h.Get(ctx, &res)
+	}
+	if err != nil {
+		return res, decisionErrorStatus(err), err
+	}
+	return res, http.StatusOK, nil
+}
+
+func decisionErrorStatus(err error) int {
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) {
+		switch appErr.Type() {
+		case workflows.ErrTypeInvalidDecision, workflows.ErrTypeUnknownTask:
+			return http.StatusUnprocessableEntity
+		case workflows.ErrTypeTaskClosed, workflows.ErrTypeDuplicateApproval:
+			return http.StatusConflict
+		}
+	}
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"time"
@@ -11,14 +12,11 @@ import (
 	"broken-order-service/internal/workflows"
 
 	"github.com/go-chi/chi/v5"
-	"go.temporal.io/api/enums/v1"
+	"github.com/google/uuid"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/client"
 )
 
-type startReq struct {
-	OrderID string `json:"orderId"`
-}
-
 type startResp struct {
 	WorkflowID string `json:"workflowId"`
 	RunID      string `json:"runId"`
@@ -33,33 +31,39 @@ func main() {
 
 	r := chi.NewRouter()
 
-	// Start a workflow execution for a given orderID.
-	// In production, we would want to trigger this from an API call or message queue event rather than a manual HTTP endpoint.
+	// Start a workflow execution for a broken-order event.
+	// Events normally arrive through cmd/ingest; this endpoint is for manual reports and demos.
 	r.Post("/workflows/start", func(w http.ResponseWriter, r *http.Request) {
-		var req startReq
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.OrderID == "" {
-			http.Error(w, "invalid body: {\"orderId\":\"...\"}", http.StatusBadRequest)
+		var ev modal.BrokenOrderEvent
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil || ev.OrderID == "" {
+			http.Error(w, "invalid body: {\"orderId\":\"...\",\"eventId\":\"...\",\"reportedIssueType\":\"...\",\"priority\":\"...\"}", http.StatusBadRequest)
 			return
 		}
 
-		// Unique workflow ID: for demo, use "resolve-<orderID>".
-		// To keep the idempotency of this endpoint, we set WorkflowExecutionErrorWhenAlreadyStarted: true, so if a workflow with the same ID is already running, it will return an error instead of starting a new one. In production, we would want a more robust strategy for generating unique workflow IDs and handling duplicates (e.g. by checking for existing workflows first, or by allowing multiple workflows per order with different IDs).
-		// In production, we might need to handle multiple workflows for the same order (e.g. if we want to allow multiple attempts to resolve the same order issue), in which case we would need a more robust strategy for generating unique workflow IDs and correlating them with orders (e.g. by using a combination of orderID and a timestamp or a UUID).
-		// In that case, we can also consider passing event_id(UUID) and order_id as workflow input, and use event_id as workflow ID for uniqueness, and order_id for querying and correlation.
-		wid := "resolve-" + req.OrderID
-
-		opts := client.StartWorkflowOptions{
-			ID:                                       wid,
-			TaskQueue:                                workflows.TaskQueue,
-			WorkflowExecutionTimeout:                 1 * time.Minute,
-			WorkflowExecutionErrorWhenAlreadyStarted: true,
-			WorkflowIDReusePolicy:                    enums.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE,
+		// The event ID is the workflow ID, so resending the same event is rejected as a duplicate while a new
+		// incident for the same order starts a new workflow. Callers that retry should send their own eventId;
+		// without one every request is treated as a new incident.
+		if ev.EventID == "" {
+			ev.EventID = uuid.NewString()
+		}
+		if ev.Source == "" {
+			ev.Source = "api"
+		}
+		ev.ReceivedAt = time.Now().UTC()
+		if err := ev.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		we, err := tc.ExecuteWorkflow(ctx, opts, workflows.ResolveBrokenOrder, req.OrderID)
+		we, err := tc.ExecuteWorkflow(ctx, workflows.StartOptions(ev), workflows.ResolveBrokenOrder, ev)
+		var started *serviceerror.WorkflowExecutionAlreadyStarted
+		if errors.As(err, &started) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -113,6 +117,28 @@ func main() {
 		writeJSON(w, task)
 	})
 
+	r.Get("/workflows/{workflowId}/tasks", func(w http.ResponseWriter, r *http.Request) {
+		workflowID := chi.URLParam(r, "workflowId")
+		runID := r.URL.Query().Get("runId")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		qr, err := tc.QueryWorkflow(ctx, workflowID, runID, "tasks")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var tasks []modal.HumanTask
+		if err := qr.Get(&tasks); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, tasks)
+	})
+
 	r.Get("/workflows/{workflowId}/audit", func(w http.ResponseWriter, r *http.Request) {
 		workflowID := chi.URLParam(r, "workflowId")
 		runID := r.URL.Query().Get("runId")
@@ -135,6 +161,28 @@ func main() {
 		writeJSON(w, events)
 	})
 
+	r.Get("/workflows/{workflowId}/attempts", func(w http.ResponseWriter, r *http.Request) {
+		workflowID := chi.URLParam(r, "workflowId")
+		runID := r.URL.Query().Get("runId")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		qr, err := tc.QueryWorkflow(ctx, workflowID, runID, "attempts")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var attempts []modal.ActionAttemp
+		if err := qr.Get(&attempts); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, attempts)
+	})
+
 	r.Post("/workflows/{workflowId}/task/decision", func(w http.ResponseWriter, r *http.Request) {
 		workflowID := chi.URLParam(r, "workflowId")
 		runID := r.URL.Query().Get("runId")
@@ -149,10 +197,30 @@ func main() {
 		}
 		d.DecidedAt = time.Now().UTC()
 
+		res, status, err := decideTask(tc, workflowID, runID, d)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		writeJSON(w, res)
+	})
+
+	r.Post("/workflows/{workflowId}/task/claim", func(w http.ResponseWriter, r *http.Request) {
+		workflowID := chi.URLParam(r, "workflowId")
+		runID := r.URL.Query().Get("runId")
+
+		var c modal.TaskClaim
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil || c.TaskID == "" || c.Claimant == "" {
+			http.Error(w, "invalid body: {\"taskId\":\"...\",\"claimant\":\"...\"}", http.StatusBadRequest)
+			return
+		}
+		c.ClaimedAt = time.Now().UTC()
+
 		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 		defer cancel()
 
-		if err := tc.SignalWorkflow(ctx, workflowID, runID, workflows.TaskDecisionSignal, d); err != nil {
+		if err := tc.SignalWorkflow(ctx, workflowID, runID, workflows.TaskClaimSignal, c); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
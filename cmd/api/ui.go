@@ -3,13 +3,16 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"html/template"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	workflowpb "go.temporal.io/api/workflow/v1"
 	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
 
 	"broken-order-service/internal/modal"
 	"broken-order-service/internal/workflows"
@@ -20,39 +23,76 @@ type uiServer struct {
 	t  *template.Template
 }
 
-type uiTaskRow struct {
-	WorkflowID string
-	RunID      string
-	Task       modal.HumanTask
+// uiWorkflowRow is one execution in a list, filled from its search attributes alone (no workflow query).
+type uiWorkflowRow struct {
+	WorkflowID       string
+	RunID            string
+	StartTime        time.Time
+	OrderID          string
+	IssueType        string
+	ResolutionStatus string
+	HasPendingTask   bool
+	Tier             string
+	AssignedTo       []string
 }
 
 type uiIndexData struct {
 	Tab   string
 	Query string
-	Tasks []uiTaskRow
-	Hits  []uiTaskRow // reuse row type for search results
-	Error string
+	// Tier and Assignee filter the tasks tab.
+	Tier     string
+	Assignee string
+	Tasks    []uiWorkflowRow
+	Hits     []uiWorkflowRow // reuse row type for search results
+	Error    string
 }
 
 type uiDetailData struct {
 	WorkflowID string
 	RunID      string
 	CaseFile   modal.CaseFile
-	Task       modal.HumanTask
+	Tasks      []modal.HumanTask
 	Audit      []modal.AuditEvent
 	Error      string
+	// DecisionError is shown next to task DecisionTaskID when a submitted decision was rejected.
+	DecisionTaskID string
+	DecisionError  string
+	// Decision is the result of a decision just submitted from this page.
+	Decision *modal.DecisionResult
+}
+
+// DecisionTaskOpen reports whether the task a rejected decision was for is still open (and shows the error itself).
+func (d uiDetailData) DecisionTaskOpen() bool {
+	for _, t := range d.Tasks {
+		if t.ID == d.DecisionTaskID && t.Status.Active() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasOpenTask reports whether any task still awaits a decision.
+func (d uiDetailData) HasOpenTask() bool {
+	for _, t := range d.Tasks {
+		if t.Status.Active() {
+			return true
+		}
+	}
+	return false
 }
 
 func registerUIRoutes(r chi.Router, tc client.Client) {
-	t := template.Must(template.New("base").Parse(uiTemplates))
+	t := template.Must(template.New("base").Funcs(uiFuncs).Parse(uiTemplates))
 	s := &uiServer{tc: tc, t: t}
 
 	r.Get("/ui", s.handleIndex)
 	r.Get("/ui/wf/{workflowId}", s.handleDetail)
 	r.Post("/ui/wf/{workflowId}/decision", s.handleDecision)
+	r.Post("/ui/wf/{workflowId}/claim", s.handleClaim)
 }
 
-// handleIndex lists workflows and their pending tasks (if any). It also supports searching by OrderID via visibility query.
+// handleIndex lists workflows with pending tasks, or searches executions by OrderID. Both tabs are a single
+// visibility query over the custom search attributes the workflow maintains (see workflows/search_attributes.go).
 func (s *uiServer) handleIndex(w http.ResponseWriter, r *http.Request) {
 	tab := r.URL.Query().Get("tab")
 	if tab == "" {
@@ -60,7 +100,12 @@ func (s *uiServer) handleIndex(w http.ResponseWriter, r *http.Request) {
 	}
 	q := r.URL.Query().Get("q")
 
-	data := uiIndexData{Tab: tab, Query: q}
+	data := uiIndexData{
+		Tab:      tab,
+		Query:    q,
+		Tier:     r.URL.Query().Get("tier"),
+		Assignee: r.URL.Query().Get("assignee"),
+	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 8*time.Second)
 	defer cancel()
@@ -68,25 +113,24 @@ func (s *uiServer) handleIndex(w http.ResponseWriter, r *http.Request) {
 	// Build list filter depending on tab
 	var query string
 	switch tab {
-	case "tasks":
-		// Only running workflows (so pending task query is relevant).
-		// Optionally scope to workflow type if you want:
-		// query = `ExecutionStatus = "Running" AND WorkflowType = "ResolveBrokenOrder"`
-		query = `ExecutionStatus = "Running"`
 	case "search":
-		// Search ALL executions by orderID by leveraging WorkflowId prefix:
-		// e.g. resolve-ORDER-42
-		// STARTS_WITH is supported for Keyword attributes like WorkflowId. :contentReference[oaicite:1]{index=1}
+		// Search ALL executions for an order through the OrderId search attribute. Workflow IDs are per event,
+		// so an order reported more than once can have several executions.
 		if q == "" {
 			// No query => return empty results fast
 			_ = s.t.ExecuteTemplate(w, "index", data)
 			return
 		}
-		query = `WorkflowId STARTS_WITH "resolve-` + q + `"`
+		query = fmt.Sprintf(`%s = %q`, workflows.OrderIDKey.GetName(), q)
 	default:
-		tab = "tasks"
 		data.Tab = "tasks"
-		query = `ExecutionStatus = "Running"`
+		query = fmt.Sprintf(`ExecutionStatus = "Running" AND %s = true`, workflows.HasPendingTaskKey.GetName())
+		if data.Tier != "" {
+			query += fmt.Sprintf(` AND %s = %q`, workflows.TierKey.GetName(), data.Tier)
+		}
+		if data.Assignee != "" {
+			query += fmt.Sprintf(` AND %s = %q`, workflows.AssignedToKey.GetName(), data.Assignee)
+		}
 	}
 
 	resp, err := s.tc.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
@@ -99,61 +143,55 @@ func (s *uiServer) handleIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// tab=tasks: only return human tasks
-	if tab == "tasks" {
-		for _, ex := range resp.Executions {
-			if ex.Execution == nil {
-				continue
-			}
-			wid := ex.Execution.WorkflowId
-			rid := ex.Execution.RunId
-
-			task, err := s.queryPendingTask(r.Context(), wid, rid)
-			if err != nil {
-				// Ignore noisy workflows / transient query failures in MVP
-				continue
-			}
-			if task.ID == "" {
-				continue
-			}
-
-			data.Tasks = append(data.Tasks, uiTaskRow{
-				WorkflowID: wid,
-				RunID:      rid,
-				Task:       task,
-			})
-
-			// Optional: cap for UI speed
-			if len(data.Tasks) >= 100 {
-				break
-			}
-		}
-
-		_ = s.t.ExecuteTemplate(w, "index", data)
-		return
-	}
-
-	// tab=search: return all executions matched by visibility query
 	for _, ex := range resp.Executions {
 		if ex.Execution == nil {
 			continue
 		}
-		data.Hits = append(data.Hits, uiTaskRow{
-			WorkflowID: ex.Execution.WorkflowId,
-			RunID:      ex.Execution.RunId,
-			// NOTE: intentionally NOT querying pending_task/casefile here (fast search).
-		})
+		if data.Tab == "tasks" {
+			data.Tasks = append(data.Tasks, workflowRow(ex))
+		} else {
+			data.Hits = append(data.Hits, workflowRow(ex))
+		}
 	}
 
 	_ = s.t.ExecuteTemplate(w, "index", data)
 }
 
-// handleDetail shows workflow details: casefile, pending task (if any), and audit log.
+// workflowRow decodes the custom search attributes of a listed execution. Attributes the workflow has not
+// set yet are left empty.
+func workflowRow(ex *workflowpb.WorkflowExecutionInfo) uiWorkflowRow {
+	row := uiWorkflowRow{
+		WorkflowID: ex.Execution.WorkflowId,
+		RunID:      ex.Execution.RunId,
+	}
+	if ex.GetStartTime() != nil {
+		row.StartTime = ex.GetStartTime().AsTime()
+	}
+	fields := ex.GetSearchAttributes().GetIndexedFields()
+	decode := func(name string, v any) {
+		if p, ok := fields[name]; ok {
+			_ = converter.GetDefaultDataConverter().FromPayload(p, v)
+		}
+	}
+	decode(workflows.OrderIDKey.GetName(), &row.OrderID)
+	decode(workflows.IssueTypeKey.GetName(), &row.IssueType)
+	decode(workflows.ResolutionStatusKey.GetName(), &row.ResolutionStatus)
+	decode(workflows.HasPendingTaskKey.GetName(), &row.HasPendingTask)
+	decode(workflows.TierKey.GetName(), &row.Tier)
+	decode(workflows.AssignedToKey.GetName(), &row.AssignedTo)
+	return row
+}
+
+// handleDetail shows workflow details: casefile, human tasks, and audit log.
 func (s *uiServer) handleDetail(w http.ResponseWriter, r *http.Request) {
 	wid := chi.URLParam(r, "workflowId")
 	rid := r.URL.Query().Get("runId")
+	s.renderDetail(w, r, uiDetailData{WorkflowID: wid, RunID: rid})
+}
 
-	data := uiDetailData{WorkflowID: wid, RunID: rid}
+// renderDetail fills in the workflow's case file, tasks and audit log and renders the detail page.
+func (s *uiServer) renderDetail(w http.ResponseWriter, r *http.Request, data uiDetailData) {
+	wid, rid := data.WorkflowID, data.RunID
 
 	cf, err := s.queryCaseFile(r.Context(), wid, rid)
 	if err != nil {
@@ -163,8 +201,8 @@ func (s *uiServer) handleDetail(w http.ResponseWriter, r *http.Request) {
 	}
 	data.CaseFile = cf
 
-	task, _ := s.queryPendingTask(r.Context(), wid, rid)
-	data.Task = task
+	tasks, _ := s.queryTasks(r.Context(), wid, rid)
+	data.Tasks = tasks
 
 	audit, _ := s.queryAudit(r.Context(), wid, rid)
 	data.Audit = audit
@@ -191,18 +229,47 @@ func (s *uiServer) handleDecision(w http.ResponseWriter, r *http.Request) {
 		Notes:     notes,
 		Decider:   decider,
 		DecidedAt: time.Now().UTC(),
+		IssueType: modal.IssueType(r.FormValue("issueType")),
+	}
+
+	// Rejected decisions (unknown or already-decided task, repeat approval) are shown next to the task.
+	res, status, err := decideTask(s.tc, wid, rid, d)
+	if err != nil {
+		w.WriteHeader(status)
+		s.renderDetail(w, r, uiDetailData{
+			WorkflowID:     wid,
+			RunID:          rid,
+			DecisionTaskID: taskID,
+			DecisionError:  err.Error(),
+		})
+		return
+	}
+
+	// Re-render the page with what the decision did to the task and the workflow.
+	s.renderDetail(w, r, uiDetailData{WorkflowID: wid, RunID: rid, Decision: &res})
+}
+
+// handleClaim handles form submission for claiming a human task.
+func (s *uiServer) handleClaim(w http.ResponseWriter, r *http.Request) {
+	wid := chi.URLParam(r, "workflowId")
+	rid := r.URL.Query().Get("runId")
+
+	c := modal.TaskClaim{
+		TaskID:    r.FormValue("taskId"),
+		Claimant:  r.FormValue("claimant"),
+		ClaimedAt: time.Now().UTC(),
+	}
+	if c.Claimant == "" {
+		c.Claimant = "ops-agent"
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	if err := s.tc.SignalWorkflow(ctx, wid, rid, workflows.TaskDecisionSignal, d); err != nil {
+	if err := s.tc.SignalWorkflow(ctx, wid, rid, workflows.TaskClaimSignal, c); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-
-	// Refresh page after decision.
-	// In a real UI, we would want to handle this more elegantly (e.g. AJAX + partial refresh), but for MVP this is sufficient.
 	http.Redirect(w, r, "/ui/wf/"+wid+"?runId="+rid, http.StatusSeeOther)
 }
 
@@ -219,17 +286,17 @@ func (s *uiServer) queryCaseFile(ctx context.Context, wid, rid string) (modal.Ca
 	return cf, qr.Get(&cf)
 }
 
-// queryPendingTask queries the workflow for the current pending human task (if any).
-func (s *uiServer) queryPendingTask(ctx context.Context, wid, rid string) (modal.HumanTask, error) {
+// queryTasks queries the workflow for all of its human tasks, oldest first.
+func (s *uiServer) queryTasks(ctx context.Context, wid, rid string) ([]modal.HumanTask, error) {
 	cctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	qr, err := s.tc.QueryWorkflow(cctx, wid, rid, "pending_task")
+	qr, err := s.tc.QueryWorkflow(cctx, wid, rid, "tasks")
 	if err != nil {
-		return modal.HumanTask{}, err
+		return nil, err
 	}
-	var t modal.HumanTask
-	return t, qr.Get(&t)
+	var tasks []modal.HumanTask
+	return tasks, qr.Get(&tasks)
 }
 
 // queryAudit queries the workflow for the audit log.
@@ -251,6 +318,21 @@ func prettyJSON(v any) template.HTML {
 	return template.HTML("<pre>" + template.HTMLEscapeString(string(b)) + "</pre>")
 }
 
+// uiFuncs are helpers available to the templates.
+var uiFuncs = template.FuncMap{
+	"json":  prettyJSON,
+	"money": modal.FormatAmount,
+	"issueTypes": func() []modal.IssueType {
+		return modal.IssueTypes
+	},
+	"ts": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.UTC().Format("2006-01-02 15:04 MST")
+	},
+}
+
 // uiTemplates contains HTML templates for the UI pages. In a real application, these would be in separate .html files.
 const uiTemplates = `
 {{define "index"}}
@@ -280,15 +362,28 @@ const uiTemplates = `
 
   {{if eq .Tab "tasks"}}
     <h3>Open Human Tasks</h3>
-    <p class="muted">List open workflows, query pending task per workflow (UI-grade; not optimized).</p>
+    <p class="muted">Running workflows with a task awaiting a decision (HasPendingTask search attribute). Open a workflow to see and decide its tasks.</p>
+    <form method="get" action="/ui">
+      <input type="hidden" name="tab" value="tasks"/>
+      <select name="tier">
+        <option value="">Any tier</option>
+        <option value="TIER1"{{if eq .Tier "TIER1"}} selected{{end}}>TIER1</option>
+        <option value="TIER2"{{if eq .Tier "TIER2"}} selected{{end}}>TIER2</option>
+      </select>
+      <input name="assignee" placeholder="assigned to" value="{{.Assignee}}"/>
+      <button type="submit">Filter</button>
+    </form>
     <table>
-      <thead><tr><th>Task</th><th>OrderID</th><th>Type</th><th>Workflow</th></tr></thead>
+      <thead><tr><th>OrderID</th><th>Issue</th><th>Tier</th><th>Assigned To</th><th>Status</th><th>Started</th><th>Workflow</th></tr></thead>
       <tbody>
       {{range .Tasks}}
         <tr>
-          <td>{{.Task.ID}}</td>
-          <td>{{.Task.OrderID}}</td>
-          <td>{{.Task.Type}}</td>
+          <td>{{.OrderID}}</td>
+          <td>{{.IssueType}}</td>
+          <td>{{or .Tier "-"}}</td>
+          <td>{{range $i, $a := .AssignedTo}}{{if $i}}, {{end}}{{$a}}{{else}}-{{end}}</td>
+          <td>{{.ResolutionStatus}}</td>
+          <td>{{ts .StartTime}}</td>
           <td><a href="/ui/wf/{{.WorkflowID}}?runId={{.RunID}}">{{.WorkflowID}}</a></td>
         </tr>
       {{end}}
@@ -305,13 +400,16 @@ const uiTemplates = `
     {{if .Query}}
       <h4>Results</h4>
       <table>
-        <thead><tr><th>OrderID</th><th>Workflow</th><th>Has Task?</th></tr></thead>
+        <thead><tr><th>OrderID</th><th>Workflow</th><th>Issue</th><th>Status</th><th>Has Task?</th><th>Started</th></tr></thead>
         <tbody>
         {{range .Hits}}
           <tr>
-            <td>{{$.Query}}</td>
+            <td>{{.OrderID}}</td>
             <td><a href="/ui/wf/{{.WorkflowID}}?runId={{.RunID}}">{{.WorkflowID}}</a></td>
-            <td>{{if .Task.ID}}Yes{{else}}No{{end}}</td>
+            <td>{{.IssueType}}</td>
+            <td>{{.ResolutionStatus}}</td>
+            <td>{{if .HasPendingTask}}Yes{{else}}No{{end}}</td>
+            <td>{{ts .StartTime}}</td>
           </tr>
         {{end}}
         </tbody>
@@ -331,6 +429,7 @@ const uiTemplates = `
   <style>
     body { font-family: sans-serif; margin: 24px; }
     .err { color: #b00020; }
+    .ok { color: #1b5e20; }
     pre { background: #f7f7f7; padding: 12px; overflow: auto; }
     table { border-collapse: collapse; width: 100%; margin-top: 12px; }
     th, td { border: 1px solid #ddd; padding: 8px; }
@@ -343,24 +442,151 @@ const uiTemplates = `
   {{if .Error}}<p class="err">{{.Error}}</p>{{end}}
 
   <p><b>WorkflowID:</b> {{.WorkflowID}}<br/>
-     <b>RunID:</b> {{.RunID}}</p>
+     <b>RunID:</b> {{.RunID}}{{with .CaseFile.ParentWorkflowID}}<br/>
+     <b>Parent:</b> <a href="/ui/wf/{{.}}">{{.}}</a>{{end}}</p>
 
   <h3>Case File</h3>
-  {{.CaseFile | printf "%#v" | html}}
+  {{with .CaseFile}}
+  <table>
+    <tr><th>OrderID</th><td>{{.OrderID}}</td><th>Issue</th><td>{{.IssueType}}</td></tr>
+    <tr><th>Transfer</th><td>{{.TransferStatus}} ({{.AttemptCount}} attempts this run)</td><th>Payment</th><td>{{.PaymentStatus}}</td></tr>
+    <tr><th>Buyer</th><td>{{.BuyerEmail}}</td><th>Generated</th><td>{{ts .GeneratedAt}}</td></tr>
+    <tr><th>Event</th><td>{{.Event.EventID}} from {{or .Event.Source "-"}} ({{or .Event.Priority "-"}})</td><th>Reported</th><td>{{or .Event.ReportedIssueType "-"}} at {{ts .Event.ReceivedAt}}</td></tr>
+    <tr><th>Classified</th><td colspan="3">{{.Classification.IssueType}} / {{.Classification.Tier}}, confidence {{printf "%.2f" .Classification.Confidence}} ({{.Classification.Classifier}}){{range .Classification.Reasons}}; {{.}}{{end}}</td></tr>
+    <tr><th>Sources</th><td colspan="3">{{range $name, $status := .Sections}}<span{{if eq $status "UNAVAILABLE"}} class="err"{{end}}>{{$name}}: {{$status}}</span> {{end}}</td></tr>
+  </table>
 
-  <h3>Pending Task</h3>
-  {{if .Task.ID}}
-    <p><b>{{.Task.Title}}</b><br/>{{.Task.Reason}}</p>
+  <h4>Order</h4>
+  <table>
+    <tr><th>Event</th><td>{{.Order.EventName}} @ {{.Order.Venue}}</td><th>Event date</th><td>{{ts .Order.EventDate}}</td></tr>
+    <tr><th>Amount</th><td>{{money .Order.AmountCents .Order.Currency}}</td><th>Purchased</th><td>{{ts .Order.PurchasedAt}}</td></tr>
+    <tr><th>Listing</th><td>{{.Order.ListingID}}</td><th>Supplier</th><td>{{.Order.SupplierID}}</td></tr>
+    <tr><th>Seats</th><td colspan="3">{{range $i, $s := .Order.Seats}}{{if $i}}, {{end}}{{$s}}{{else}}-{{end}}</td></tr>
+  </table>
+
+  <h4>Refund Eligibility</h4>
+  <p>{{if .RefundEligibility.Eligible}}Eligible up to {{money .RefundEligibility.MaxRefundCents .Order.Currency}}{{else}}Not eligible{{end}}
+     ({{.RefundEligibility.Policy}}{{if .RefundEligibility.Reason}}: {{.RefundEligibility.Reason}}{{end}})</p>
+
+  <h4>Timeline</h4>
+  <table>
+    <thead><tr><th>Time</th><th>Source</th><th>Event</th></tr></thead>
+    <tbody>
+      {{range .Timeline}}
+        <tr><td>{{ts .At}}</td><td>{{.Source}}</td><td>{{.Summary}}</td></tr>
+      {{else}}
+        <tr><td colspan="3">(no events)</td></tr>
+      {{end}}
+    </tbody>
+  </table>
+
+  <h4>Supplier Comms</h4>
+  <table>
+    <thead><tr><th>Time</th><th>Direction</th><th>Message</th></tr></thead>
+    <tbody>
+      {{range .SupplierComms}}
+        <tr><td>{{ts .At}}</td><td>{{.Direction}}</td><td>{{.Body}}</td></tr>
+      {{else}}
+        <tr><td colspan="3">(no messages)</td></tr>
+      {{end}}
+    </tbody>
+  </table>
+
+  <h4>Prior Transfer Attempts</h4>
+  <table>
+    <thead><tr><th>Time</th><th>Attempt</th><th>Action</th><th>Result</th></tr></thead>
+    <tbody>
+      {{range .TransferAttempts}}
+        <tr><td>{{ts .AttemptedAt}}</td><td>{{.AttemptID}}</td><td>{{.ActionType}}</td><td>{{.Result}}</td></tr>
+      {{else}}
+        <tr><td colspan="4">(no prior attempts)</td></tr>
+      {{end}}
+    </tbody>
+  </table>
+
+  {{if .Children}}
+  <h4>Child Workflows</h4>
+  <table>
+    <thead><tr><th>Workflow</th><th>Type</th><th>Step</th><th>Status</th><th>Outcome</th><th>Started</th><th>Closed</th></tr></thead>
+    <tbody>
+      {{range .Children}}
+        <tr><td><a href="/ui/wf/{{.WorkflowID}}?runId={{.RunID}}">{{.WorkflowID}}</a></td><td>{{.Type}}</td><td>{{.StepID}}</td>
+          <td{{if or (eq .Status "FAILED") (eq .Status "CANCELLED")}} class="err"{{end}}>{{.Status}}</td>
+          <td>{{or .Outcome .Error "-"}}</td><td>{{ts .StartedAt}}</td><td>{{ts .ClosedAt}}</td></tr>
+      {{end}}
+    </tbody>
+  </table>
+  {{end}}
+
+  {{with .Replacement}}
+  <h4>Replacement Seats</h4>
+  <table>
+    <tr><th>Reservation</th><td>{{.ReservationID}} ({{.Status}})</td><th>Expires</th><td>{{ts .ExpiresAt}}</td></tr>
+    <tr><th>Listing</th><td>{{.Listing.ListingID}} from {{.Listing.SupplierID}}</td><th>Price</th><td>{{money .Listing.PriceCents .Listing.Currency}}</td></tr>
+    <tr><th>Seats</th><td colspan="3">{{range $i, $s := .Listing.Seats}}{{if $i}}, {{end}}{{$s}}{{else}}-{{end}}</td></tr>
+  </table>
+  {{end}}
 
-    <form method="post" action="/ui/wf/{{.WorkflowID}}/decision?runId={{.RunID}}">
-      <input type="hidden" name="taskId" value="{{.Task.ID}}"/>
+  <details><summary>Raw case file</summary>{{json .}}</details>
+  {{end}}
+
+  <h3>Open Tasks</h3>
+  {{with .Decision}}<p class="ok">Decision for {{.TaskID}} recorded: task {{.TaskStatus}} ({{.Approvals}}/{{.RequiredApprovals}} approvals), workflow {{.WorkflowStatus}}.</p>{{end}}
+  {{range .Tasks}}{{if .Status.Active}}
+    <div style="border: 1px solid #ddd; padding: 8px; margin-bottom: 12px;">
+    {{if eq $.DecisionTaskID .ID}}<p class="err">Decision rejected: {{$.DecisionError}}</p>{{end}}
+    <p><b>{{.Title}}</b> <span class="muted">({{.Type}}, {{.Status}}{{if .ClaimedBy}} by {{.ClaimedBy}}{{end}}, {{.Tier}})</span><br/>{{.Reason}}</p>
+    {{if not .DueAt.IsZero}}<p class="muted">Due {{ts .DueAt}}{{if not .DeadlineAt.IsZero}}; default action at {{ts .DeadlineAt}}{{end}}</p>{{end}}
+    {{with .Refund}}
+      <table>
+        <tr><th>Proposed refund</th><td>{{money .AmountCents .Currency}}</td></tr>
+        <tr><th>Policy</th><td>{{.Policy}}</td></tr>
+        <tr><th>Rationale</th><td>{{.Rationale}}</td></tr>
+      </table>
+    {{end}}
+    {{with .Classification}}
+      <p>Suggested issue type: <b>{{.IssueType}}</b> (confidence {{printf "%.2f" .Confidence}}). Approve to confirm it or pick another; reject to handle the order manually.</p>
+    {{end}}
+    {{if gt .RequiredApprovals 1}}
+      <p>Requires {{.RequiredApprovals}} distinct approvers; approved so far by:
+        {{range .Approvals}}<b>{{.Decider}}</b> {{else}}(nobody yet){{end}}</p>
+    {{end}}
+
+    {{if not .ClaimedBy}}
+    <form method="post" action="/ui/wf/{{$.WorkflowID}}/claim?runId={{$.RunID}}">
+      <input type="hidden" name="taskId" value="{{.ID}}"/>
+      <label>Claim as: <input name="claimant" value="richard"/></label>
+      <button type="submit">Claim</button>
+    </form><br/>
+    {{end}}
+    <form method="post" action="/ui/wf/{{$.WorkflowID}}/decision?runId={{$.RunID}}">
+      <input type="hidden" name="taskId" value="{{.ID}}"/>
       <label>Decider: <input name="decider" value="richard"/></label><br/><br/>
       <label>Notes:<br/><textarea name="notes" rows="3" cols="80"></textarea></label><br/><br/>
+      {{with .Classification}}{{$suggested := .IssueType}}
+      <label>Issue type: <select name="issueType">
+        {{range issueTypes}}<option value="{{.}}"{{if eq . $suggested}} selected{{end}}>{{.}}</option>{{end}}
+      </select></label><br/><br/>
+      {{end}}
       <button name="approved" value="true" type="submit">Approve</button>
       <button name="approved" value="false" type="submit">Reject</button>
     </form>
-  {{else}}
-    <p>(No pending task)</p>
+    </div>
+  {{end}}{{end}}
+  {{if not .HasOpenTask}}<p>(No pending task)</p>{{end}}
+  {{if and .DecisionError (not .DecisionTaskOpen)}}<p class="err">Decision for {{.DecisionTaskID}} rejected: {{.DecisionError}}</p>{{end}}
+
+  {{if .Tasks}}
+  <h3>All Tasks</h3>
+  <table>
+    <thead><tr><th>Task</th><th>Type</th><th>Status</th><th>Created</th><th>Closed</th><th>Approvals</th></tr></thead>
+    <tbody>
+      {{range .Tasks}}
+        <tr><td>{{.ID}}</td><td>{{.Type}}</td><td>{{.Status}}</td><td>{{ts .CreatedAt}}</td><td>{{ts .ClosedAt}}</td>
+          <td>{{range .Approvals}}{{.Decider}} {{end}}</td></tr>
+      {{end}}
+    </tbody>
+  </table>
   {{end}}
 
   <h3>Audit Log</h3>
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"broken-order-service/internal/adapters"
+	"broken-order-service/internal/eval"
+	"broken-order-service/internal/playbook"
+)
+
+// eval replays the golden cases through the classifier and the workflow and prints a scored report.
+// It needs no Temporal server: cases run in the SDK's test environment against the fake adapters.
+// The exit status is 1 if any case fails, so it can gate CI.
+func main() {
+	var dir, scenarioPath, playbookDir string
+	var verbose bool
+	flag.StringVar(&dir, "dir", "evals/golden", "directory of golden cases (.yaml/.yml/.json)")
+	flag.StringVar(&scenarioPath, "scenario", "scenarios/demo.yaml", "base scenario for the fake adapters")
+	flag.StringVar(&playbookDir, "playbooks", "", "optional directory of playbook overrides")
+	flag.BoolVar(&verbose, "v", false, "show passing rules, classifications and activity output")
+	flag.Parse()
+
+	cases, err := eval.LoadCases(dir)
+	if err != nil {
+		log.Fatalf("unable to load cases: %v", err)
+	}
+	sc, err := adapters.LoadScenario(scenarioPath)
+	if err != nil {
+		log.Fatalf("unable to load scenario: %v", err)
+	}
+	r := &eval.Runner{Scenario: sc, Playbooks: playbook.NewStore(playbookDir)}
+
+	// Activities log to stdout; keep the report readable unless asked for the detail.
+	stdout := os.Stdout
+	if !verbose {
+		if devnull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+			os.Stdout = devnull
+		}
+	}
+	var report eval.Report
+	for _, c := range cases {
+		report.Cases = append(report.Cases, eval.Score(c, r.Run(c)))
+	}
+	os.Stdout = stdout
+
+	report.Write(os.Stdout, verbose)
+	if !report.Passed() {
+		fmt.Fprintln(os.Stderr, "eval: some cases failed")
+		os.Exit(1)
+	}
+}
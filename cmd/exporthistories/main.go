@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/api/temporalproto"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+)
+
+// exporthistories writes ResolveBrokenOrder histories from the local Temporal server to testdata/histories, one JSON
+// file per workflow in the format `temporal workflow show --output json` produces. The replay test in
+// internal/workflows replays every file there, so exporting a few runs of each path (including ones still waiting on
+// a human decision) guards them against non-deterministic changes to the workflow.
+func main() {
+	var (
+		namespace  string
+		workflowID string
+		query      string
+		outDir     string
+		limit      int
+	)
+	flag.StringVar(&namespace, "namespace", "default", "Temporal namespace")
+	flag.StringVar(&workflowID, "workflow", "", "export only this workflow ID (latest run)")
+	flag.StringVar(&query, "query", "WorkflowType = 'ResolveBrokenOrder'", "visibility query selecting the workflows to export")
+	flag.StringVar(&outDir, "out", "testdata/histories", "directory to write histories to")
+	flag.IntVar(&limit, "limit", 20, "maximum number of workflows to export")
+	flag.Parse()
+
+	c, err := client.Dial(client.Options{HostPort: "localhost:7233", Namespace: namespace})
+	if err != nil {
+		log.Fatalf("unable to create Temporal client: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		log.Fatalf("unable to create %s: %v", outDir, err)
+	}
+
+	ids := []string{workflowID}
+	if workflowID == "" {
+		if ids, err = listWorkflows(ctx, c, namespace, query, limit); err != nil {
+			log.Fatalf("unable to list workflows: %v", err)
+		}
+	}
+	for _, id := range ids {
+		path := filepath.Join(outDir, id+".json")
+		if err := export(ctx, c, id, path); err != nil {
+			log.Fatalf("unable to export %s: %v", id, err)
+		}
+		log.Printf("exported %s to %s\n", id, path)
+	}
+	if len(ids) == 0 {
+		log.Printf("no workflows match %q\n", query)
+	}
+}
+
+// listWorkflows returns the IDs of up to limit workflows matching query, newest first.
+func listWorkflows(ctx context.Context, c client.Client, namespace, query string, limit int) ([]string, error) {
+	var ids []string
+	var token []byte
+	for len(ids) < limit {
+		resp, err := c.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+			Namespace:     namespace,
+			Query:         query,
+			NextPageToken: token,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, ex := range resp.GetExecutions() {
+			if ex.GetType().GetName() != "ResolveBrokenOrder" || len(ids) == limit {
+				continue
+			}
+			ids = append(ids, ex.GetExecution().GetWorkflowId())
+		}
+		if token = resp.GetNextPageToken(); len(token) == 0 {
+			break
+		}
+	}
+	return ids, nil
+}
+
+func export(ctx context.Context, c client.Client, workflowID, path string) error {
+	hist := &historypb.History{}
+	iter := c.GetWorkflowHistory(ctx, workflowID, "", false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	for iter.HasNext() {
+		ev, err := iter.Next()
+		if err != nil {
+			return err
+		}
+		hist.Events = append(hist.Events, ev)
+	}
+	if len(hist.Events) == 0 {
+		return fmt.Errorf("empty history")
+	}
+
+	b, err := temporalproto.CustomJSONMarshalOptions{Indent: "  "}.Marshal(hist)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"broken-order-service/internal/ingest"
+	"broken-order-service/internal/workflows"
+
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+)
+
+// ingest consumes broken-order events and starts a ResolveBrokenOrder workflow for each one.
+// Locally the "broker" is a JSONL file or a directory of JSONL files (see events/demo.jsonl);
+// in production an EventSource would wrap Kafka/SQS instead.
+func main() {
+	var path string
+	var follow bool
+	var concurrency int
+	var poll time.Duration
+	flag.StringVar(&path, "path", "events", "JSONL file, or directory of *.jsonl files, to read events from")
+	flag.BoolVar(&follow, "follow", true, "keep tailing for new events; false exits once the input is consumed")
+	flag.IntVar(&concurrency, "concurrency", 4, "maximum workflow starts in flight")
+	flag.DurationVar(&poll, "poll", time.Second, "how often to check for new events when following")
+	flag.Parse()
+
+	c, err := client.Dial(client.Options{HostPort: "localhost:7233"})
+	if err != nil {
+		log.Fatalf("unable to create Temporal client: %v", err)
+	}
+	defer c.Close()
+
+	src := ingest.NewFileSource(path, follow)
+	src.PollInterval = poll
+	consumer := ingest.NewConsumer(src, startWorkflow(c), concurrency)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("ingest reading %s (follow=%t, concurrency=%d)\n", path, follow, concurrency)
+	err = consumer.Run(ctx)
+	st := consumer.Stats()
+	log.Printf("ingest stopped: started=%d duplicates=%d failed=%d\n", st.Started.Load(), st.Duplicates.Load(), st.Failed.Load())
+	if err != nil {
+		log.Fatalf("ingest failed: %v", err)
+	}
+}
+
+// startWorkflow starts ResolveBrokenOrder for an event. The workflow ID is derived from the event ID, so an
+// event that already has a workflow is reported as a duplicate and re-reading the same events (e.g. after
+// a restart) is harmless.
+func startWorkflow(c client.Client) ingest.StartFunc {
+	return func(ctx context.Context, e ingest.Event) error {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		_, err := c.ExecuteWorkflow(ctx, workflows.StartOptions(e), workflows.ResolveBrokenOrder, e)
+		var started *serviceerror.WorkflowExecutionAlreadyStarted
+		if errors.As(err, &started) {
+			return ingest.ErrDuplicate
+		}
+		return err
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"broken-order-service/internal/adapters"
+	"broken-order-service/internal/modal"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// mockdeps serves fake Order, Transfer, Supplier, Payment and Inventory HTTP APIs backed by adapters.Fake,
+// driven by a scenario file. Point the worker at it with `-adapters http` to exercise the HTTP
+// adapters end to end, or to reproduce a production incident locally.
+func main() {
+	var addr, scenarioPath string
+	flag.StringVar(&addr, "addr", ":8091", "listen address")
+	flag.StringVar(&scenarioPath, "scenario", "scenarios/demo.yaml", "scenario file (YAML or JSON)")
+	flag.Parse()
+
+	s := &server{scenarioPath: scenarioPath}
+	if err := s.reset(); err != nil {
+		log.Fatalf("unable to load scenario: %v", err)
+	}
+
+	r := chi.NewRouter()
+
+	r.Get("/orders/{orderId}", func(w http.ResponseWriter, r *http.Request) {
+		o, err := s.fake().GetOrder(r.Context(), chi.URLParam(r, "orderId"))
+		respond(w, o, err)
+	})
+	r.Post("/orders/{orderId}/notifications", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Template string            `json:"template"`
+			Vars     map[string]string `json:"vars"`
+		}
+		if !decode(w, r, &req) {
+			return
+		}
+		err := s.fake().NotifyBuyer(r.Context(), chi.URLParam(r, "orderId"), req.Template, req.Vars, idempotencyKey(r))
+		respond(w, map[string]any{"ok": true}, err)
+	})
+
+	r.Get("/transfers/{orderId}", func(w http.ResponseWriter, r *http.Request) {
+		st, err := s.fake().GetTransferStatus(r.Context(), chi.URLParam(r, "orderId"))
+		respond(w, map[string]any{"status": st}, err)
+	})
+	r.Get("/transfers/{orderId}/attempts", func(w http.ResponseWriter, r *http.Request) {
+		attempts, err := s.fake().GetTransferAttempts(r.Context(), chi.URLParam(r, "orderId"))
+		respond(w, attempts, err)
+	})
+	r.Get("/transfers/{orderId}/seats", func(w http.ResponseWriter, r *http.Request) {
+		seats, err := s.fake().GetDeliveredSeats(r.Context(), chi.URLParam(r, "orderId"))
+		respond(w, seats, err)
+	})
+	r.Get("/transfers/{orderId}/window", func(w http.ResponseWriter, r *http.Request) {
+		until, err := s.fake().GetTransferBlockedUntil(r.Context(), chi.URLParam(r, "orderId"))
+		respond(w, map[string]any{"blockedUntil": until}, err)
+	})
+	r.Post("/transfers/{orderId}/retry", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Attempt int `json:"attempt"`
+		}
+		if !decode(w, r, &req) {
+			return
+		}
+		st, err := s.fake().RetryTransfer(r.Context(), chi.URLParam(r, "orderId"), req.Attempt, idempotencyKey(r))
+		respond(w, map[string]any{"status": st}, err)
+	})
+	r.Post("/transfers/{orderId}/cancel", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			TransferKey string `json:"transferKey"`
+		}
+		if !decode(w, r, &req) {
+			return
+		}
+		err := s.fake().CancelTransfer(r.Context(), chi.URLParam(r, "orderId"), req.TransferKey, idempotencyKey(r))
+		respond(w, map[string]any{"ok": true}, err)
+	})
+	r.Post("/transfers/{orderId}/replacement", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ReservationID string `json:"reservationId"`
+		}
+		if !decode(w, r, &req) {
+			return
+		}
+		st, err := s.fake().TransferReplacement(r.Context(), chi.URLParam(r, "orderId"), req.ReservationID, idempotencyKey(r))
+		respond(w, map[string]any{"status": st}, err)
+	})
+
+	r.Get("/suppliers/orders/{orderId}/messages", func(w http.ResponseWriter, r *http.Request) {
+		msgs, err := s.fake().GetCommsHistory(r.Context(), chi.URLParam(r, "orderId"))
+		respond(w, msgs, err)
+	})
+	r.Post("/suppliers/orders/{orderId}/ping", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Message string `json:"message"`
+		}
+		if !decode(w, r, &req) {
+			return
+		}
+		err := s.fake().SendPing(r.Context(), chi.URLParam(r, "orderId"), req.Message, idempotencyKey(r))
+		respond(w, map[string]any{"ok": true}, err)
+	})
+	r.Post("/suppliers/orders/{orderId}/corrections", func(w http.ResponseWriter, r *http.Request) {
+		var req modal.SeatDiscrepancy
+		if !decode(w, r, &req) {
+			return
+		}
+		st, err := s.fake().RequestCorrection(r.Context(), chi.URLParam(r, "orderId"), req, idempotencyKey(r))
+		respond(w, map[string]any{"status": st}, err)
+	})
+
+	r.Get("/payments/{orderId}", func(w http.ResponseWriter, r *http.Request) {
+		res, err := s.fake().GetAuthorization(r.Context(), chi.URLParam(r, "orderId"))
+		respond(w, map[string]any{"declineCode": res.DeclineCode}, err)
+	})
+	r.Get("/payments/{orderId}/refund-eligibility", func(w http.ResponseWriter, r *http.Request) {
+		e, err := s.fake().GetRefundEligibility(r.Context(), chi.URLParam(r, "orderId"))
+		respond(w, e, err)
+	})
+	r.Post("/payments/{orderId}/reauthorize", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Attempt int `json:"attempt"`
+		}
+		if !decode(w, r, &req) {
+			return
+		}
+		res, err := s.fake().Reauthorize(r.Context(), chi.URLParam(r, "orderId"), req.Attempt, idempotencyKey(r))
+		respond(w, map[string]any{"declineCode": res.DeclineCode}, err)
+	})
+
+	r.Get("/payments/{orderId}/refund-proposal", func(w http.ResponseWriter, r *http.Request) {
+		p, err := s.fake().ComputeRefund(r.Context(), chi.URLParam(r, "orderId"))
+		respond(w, p, err)
+	})
+	r.Post("/payments/{orderId}/refunds", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			AmountCents int64 `json:"amountCents"`
+		}
+		if !decode(w, r, &req) {
+			return
+		}
+		res, err := s.fake().IssueRefund(r.Context(), chi.URLParam(r, "orderId"), req.AmountCents, idempotencyKey(r))
+		respond(w, res, err)
+	})
+	r.Post("/payments/{orderId}/refunds/{refundId}/void", func(w http.ResponseWriter, r *http.Request) {
+		res, err := s.fake().VoidRefund(r.Context(), chi.URLParam(r, "orderId"), chi.URLParam(r, "refundId"), idempotencyKey(r))
+		respond(w, res, err)
+	})
+
+	r.Get("/inventory/orders/{orderId}/listings", func(w http.ResponseWriter, r *http.Request) {
+		var band modal.PriceBand
+		for name, v := range map[string]*int64{"minPriceCents": &band.MinCents, "maxPriceCents": &band.MaxCents} {
+			if q := r.URL.Query().Get(name); q != "" {
+				n, err := strconv.ParseInt(q, 10, 64)
+				if err != nil {
+					http.Error(w, "invalid "+name, http.StatusBadRequest)
+					return
+				}
+				*v = n
+			}
+		}
+		listings, err := s.fake().FindComparableSeats(r.Context(), chi.URLParam(r, "orderId"), band)
+		respond(w, listings, err)
+	})
+	r.Post("/inventory/orders/{orderId}/reservations", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ListingID string `json:"listingId"`
+		}
+		if !decode(w, r, &req) {
+			return
+		}
+		res, err := s.fake().ReserveSeats(r.Context(), chi.URLParam(r, "orderId"), req.ListingID, idempotencyKey(r))
+		respond(w, res, err)
+	})
+	r.Post("/inventory/orders/{orderId}/reservations/{reservationId}/release", func(w http.ResponseWriter, r *http.Request) {
+		err := s.fake().ReleaseSeats(r.Context(), chi.URLParam(r, "orderId"), chi.URLParam(r, "reservationId"), idempotencyKey(r))
+		respond(w, map[string]any{"ok": true}, err)
+	})
+	r.Post("/inventory/orders/{orderId}/reservations/{reservationId}/purchase", func(w http.ResponseWriter, r *http.Request) {
+		res, err := s.fake().PurchaseSeats(r.Context(), chi.URLParam(r, "orderId"), chi.URLParam(r, "reservationId"), idempotencyKey(r))
+		respond(w, res, err)
+	})
+
+	// Debug endpoints: inspect an order's fake state, or reload the scenario (resets all state and faults).
+	r.Get("/_state/{orderId}", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.fake().Get(chi.URLParam(r, "orderId")))
+	})
+	r.Post("/_reset", func(w http.ResponseWriter, r *http.Request) {
+		if err := s.reset(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]any{"ok": true})
+	})
+
+	log.Printf("mockdeps listening on %s (scenario=%s)\n", addr, scenarioPath)
+	log.Fatal(http.ListenAndServe(addr, r))
+}
+
+type server struct {
+	scenarioPath string
+
+	mu sync.Mutex
+	f  *adapters.Fake
+}
+
+func (s *server) fake() *adapters.Fake {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f
+}
+
+func (s *server) reset() error {
+	sc, err := adapters.LoadScenario(s.scenarioPath)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.f = adapters.NewFakeFromScenario(sc)
+	return nil
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// idempotencyKey returns the request's idempotency key; the fake replays the first result for a repeated key.
+func idempotencyKey(r *http.Request) string {
+	return r.Header.Get(adapters.IdempotencyKeyHeader)
+}
+
+// respond writes v, or maps err to its HTTP status (injected faults keep their scripted status).
+func respond(w http.ResponseWriter, v any, err error) {
+	if err != nil {
+		status := http.StatusInternalServerError
+		var httpErr *adapters.HTTPError
+		if errors.As(err, &httpErr) {
+			status = httpErr.StatusCode
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, v)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
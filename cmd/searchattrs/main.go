@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"broken-order-service/internal/workflows"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/operatorservice/v1"
+	"go.temporal.io/sdk/client"
+)
+
+// searchattrs registers the custom search attributes the workflows use (workflows.SearchAttributes).
+// Run it once per namespace before starting workflows; attributes that already exist are left as they are.
+func main() {
+	var namespace string
+	flag.StringVar(&namespace, "namespace", "default", "Temporal namespace")
+	flag.Parse()
+
+	c, err := client.Dial(client.Options{HostPort: "localhost:7233", Namespace: namespace})
+	if err != nil {
+		log.Fatalf("unable to create Temporal client: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	existing, err := c.OperatorService().ListSearchAttributes(ctx, &operatorservice.ListSearchAttributesRequest{Namespace: namespace})
+	if err != nil {
+		log.Fatalf("unable to list search attributes: %v", err)
+	}
+
+	missing := make(map[string]enums.IndexedValueType)
+	for name, typ := range workflows.SearchAttributes {
+		if got, ok := existing.GetCustomAttributes()[name]; ok {
+			if got != typ {
+				log.Fatalf("search attribute %s is registered as %s, want %s", name, got, typ)
+			}
+			log.Printf("search attribute %s (%s) already registered\n", name, typ)
+			continue
+		}
+		missing[name] = typ
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	if _, err := c.OperatorService().AddSearchAttributes(ctx, &operatorservice.AddSearchAttributesRequest{
+		Namespace:        namespace,
+		SearchAttributes: missing,
+	}); err != nil {
+		log.Fatalf("unable to add search attributes: %v", err)
+	}
+	for name, typ := range missing {
+		log.Printf("registered search attribute %s (%s)\n", name, typ)
+	}
+}
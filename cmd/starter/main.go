@@ -1,44 +1,51 @@
 package main
 
 import (
+	"broken-order-service/internal/modal"
 	"broken-order-service/internal/workflows"
 	"context"
 	"flag"
 	"log"
 	"time"
 
-	"go.temporal.io/api/enums/v1"
+	"github.com/google/uuid"
 	"go.temporal.io/sdk/client"
 )
 
 // This is a simple starter that starts a workflow execution for demo/testing purposes.
-// For protoype/dev purposes, use a command line arg to specify orderID, and start a workflow for that orderID. In production, this would be triggered by an API call or message queue event with the orderID.
+// For protoype/dev purposes, use command line args to describe the broken-order event, and start a workflow for it. In production, this would be triggered by an API call or message queue event (see cmd/ingest).
 // Note: More details being implemented in cmd/api/main.go, this is just a simple example to demonstrate starting a workflow execution.
 func main() {
-	var orderID string
-	flag.StringVar(&orderID, "order", "ORDER-123", "order id")
+	var ev modal.BrokenOrderEvent
+	var issueType, priority string
+	flag.StringVar(&ev.OrderID, "order", "ORDER-123", "order id")
+	flag.StringVar(&ev.EventID, "event", "", "event id (default: a new UUID, i.e. a new incident)")
+	flag.StringVar(&issueType, "issue", "", "reported issue type hint, e.g. TRANSFER_FAILED")
+	flag.StringVar(&priority, "priority", string(modal.PriorityNormal), "LOW, NORMAL or HIGH")
 	flag.Parse()
 
+	if ev.EventID == "" {
+		ev.EventID = uuid.NewString()
+	}
+	ev.ReportedIssueType = modal.IssueType(issueType)
+	ev.Priority = modal.Priority(priority)
+	ev.Source = "starter"
+	ev.ReceivedAt = time.Now().UTC()
+	if err := ev.Validate(); err != nil {
+		log.Fatalf("invalid event: %v", err)
+	}
+
 	c, err := client.Dial(client.Options{HostPort: "localhost:7233"})
 	if err != nil {
 		log.Fatalf("unable to create Temporal client: %v", err)
 	}
 	defer c.Close()
 
-	// Start workflow execution.
-	// Note: in production, we want to use a more robust correlation strategy rather than just WorkflowID based on orderID.
-	opts := client.StartWorkflowOptions{
-		ID:                                       "resolve-" + orderID,
-		TaskQueue:                                workflows.TaskQueue,
-		WorkflowExecutionTimeout:                 1 * time.Minute,
-		WorkflowExecutionErrorWhenAlreadyStarted: true,
-		WorkflowIDReusePolicy:                    enums.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE,
-	}
-
+	// Start workflow execution. The workflow ID is derived from the event ID; the order ID is stored in the OrderId search attribute.
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	we, err := c.ExecuteWorkflow(ctx, opts, workflows.ResolveBrokenOrder, orderID)
+	we, err := c.ExecuteWorkflow(ctx, workflows.StartOptions(ev), workflows.ResolveBrokenOrder, ev)
 	if err != nil {
 		log.Fatalf("unable to execute workflow: %v", err)
 	}
@@ -48,11 +55,11 @@ func main() {
 	ctx2, cancel2 := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel2()
 
-	var result string
-	if err := we.Get(ctx2, &result); err != nil {
+	var res modal.Resolution
+	if err := we.Get(ctx2, &res); err != nil {
 		log.Fatalf("unable to get workflow result: %v", err)
 	}
-	log.Printf("workflow result: %s\n", result)
+	log.Printf("workflow result: %s (%s, decided by %s, %d actions)\n", res.Outcome, res.Reason, res.DecidedBy, len(res.Actions))
 }
 
 func ctxWithTimeout(d time.Duration) (ctx context.Context) {
@@ -2,7 +2,10 @@ package main
 
 import (
 	"broken-order-service/internal/activities"
+	"broken-order-service/internal/adapters"
+	"broken-order-service/internal/playbook"
 	"broken-order-service/internal/workflows"
+	"flag"
 	"log"
 
 	"go.temporal.io/sdk/client"
@@ -10,6 +13,26 @@ import (
 )
 
 func main() {
+	var (
+		playbookDir  string
+		adapterMode  string
+		scenarioPath string
+		orderURL     string
+		transferURL  string
+		supplierURL  string
+		paymentURL   string
+		inventoryURL string
+	)
+	flag.StringVar(&playbookDir, "playbooks", "", "directory of YAML/JSON playbooks overriding the built-in defaults")
+	flag.StringVar(&adapterMode, "adapters", "fake", `downstream adapters: "fake" (in-memory) or "http"`)
+	flag.StringVar(&scenarioPath, "scenario", "scenarios/demo.yaml", "scenario file driving the fake adapters")
+	flag.StringVar(&orderURL, "order-url", "http://localhost:8091", "Order service base URL (http adapters)")
+	flag.StringVar(&transferURL, "transfer-url", "http://localhost:8091", "Transfer service base URL (http adapters)")
+	flag.StringVar(&supplierURL, "supplier-url", "http://localhost:8091", "Supplier service base URL (http adapters)")
+	flag.StringVar(&paymentURL, "payment-url", "http://localhost:8091", "Payment service base URL (http adapters)")
+	flag.StringVar(&inventoryURL, "inventory-url", "http://localhost:8091", "Inventory service base URL (http adapters)")
+	flag.Parse()
+
 	c, err := client.Dial(client.Options{
 		HostPort: "localhost:7233",
 	})
@@ -21,13 +44,60 @@ func main() {
 	w := worker.New(c, workflows.TaskQueue, worker.Options{})
 	// Register workflow + activities (core worker pattern). :contentReference[oaicite:8]{index=8}
 	w.RegisterWorkflow(workflows.ResolveBrokenOrder)
+	// Child workflows started by playbook child_workflow steps (see workflows/children.go).
+	w.RegisterWorkflow(workflows.SourceReplacement)
+	w.RegisterWorkflow(workflows.NotifyBuyer)
+
+	// Pick adapter implementations for this environment.
+	playbooks := playbook.NewStore(playbookDir)
+	var a *activities.Activities
+	switch adapterMode {
+	case "fake":
+		sc, err := adapters.LoadScenario(scenarioPath)
+		if err != nil {
+			log.Fatalf("unable to load scenario: %v", err)
+		}
+		a = activities.NewFakeActivities(adapters.NewFakeFromScenario(sc), playbooks)
+	case "http":
+		a = &activities.Activities{
+			Orders:    adapters.NewHTTPOrderAdapter(orderURL),
+			Transfers: adapters.NewHTTPTransferAdapter(transferURL),
+			Suppliers: adapters.NewHTTPSupplierAdapter(supplierURL),
+			Payments:  adapters.NewHTTPPaymentAdapter(paymentURL),
+			Inventory: adapters.NewHTTPInventoryAdapter(inventoryURL),
+			Playbooks: playbooks,
+		}
+	default:
+		log.Fatalf("unknown -adapters %q (want fake or http)", adapterMode)
+	}
+
+	// Issues are classified by classify.Rules unless a.Classifier is set, e.g. to &classify.LLM{Model: m}
+	// for a model-backed classifier. Either way, low-confidence verdicts go to a human TRIAGE task.
 
 	// Register function activities that can be called from workflows.
-	a := &activities.Activities{}
-	w.RegisterActivity(a.BuildCaseFile)
+	w.RegisterActivity(a.FetchOrder)
+	w.RegisterActivity(a.FetchTransfer)
+	w.RegisterActivity(a.FetchSupplierComms)
+	w.RegisterActivity(a.FetchPayment)
 	w.RegisterActivity(a.RetryTransfer)
+	w.RegisterActivity(a.ReauthorizePayment)
+	w.RegisterActivity(a.NotifyBuyer)
+	w.RegisterActivity(a.NotifyExpectedDelivery)
+	w.RegisterActivity(a.PingSupplier)
+	w.RegisterActivity(a.RequestSeatCorrection)
+	w.RegisterActivity(a.ComputeRefund)
+	w.RegisterActivity(a.IssueRefund)
+	w.RegisterActivity(a.CancelTransfer)
+	w.RegisterActivity(a.VoidRefund)
+	w.RegisterActivity(a.FindReplacementSeats)
+	w.RegisterActivity(a.ReserveSeats)
+	w.RegisterActivity(a.ReleaseSeats)
+	w.RegisterActivity(a.PurchaseSeats)
+	w.RegisterActivity(a.TransferReplacement)
+	w.RegisterActivity(a.ClassifyIssue)
+	w.RegisterActivity(a.LoadPlaybook)
 
-	log.Printf("worker started (taskQueue=%s)\n", workflows.TaskQueue)
+	log.Printf("worker started (taskQueue=%s, adapters=%s)\n", workflows.TaskQueue, adapterMode)
 	if err := w.Run(worker.InterruptCh()); err != nil {
 		log.Fatalf("worker exited: %v", err)
 	}
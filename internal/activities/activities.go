@@ -1,53 +1,304 @@
 package activities
 
 import (
+	"broken-order-service/internal/adapters"
+	"broken-order-service/internal/classify"
 	"broken-order-service/internal/modal"
+	"broken-order-service/internal/playbook"
 	"context"
+	"errors"
 	"fmt"
-	"strings"
 	"time"
+
+	"go.temporal.io/sdk/temporal"
 )
 
-type Activities struct{}
+// Activities are the "tool calls" the workflow can make. They talk to downstream systems only through
+// the adapter interfaces, so cmd/worker decides whether they hit fakes or real services.
+type Activities struct {
+	Orders    adapters.OrderAdapter
+	Transfers adapters.TransferAdapter
+	Suppliers adapters.SupplierAdapter
+	Payments  adapters.PaymentAdapter
+	Inventory adapters.InventoryAdapter
+
+	// Playbooks resolves issue types to playbooks. Nil means built-in defaults only.
+	Playbooks *playbook.Store
+	// Classifier decides issue type and tier from the case file. Nil means classify.Rules.
+	Classifier classify.Classifier
+}
+
+// NewFakeActivities wires every adapter to the same in-memory fake (local dev and tests).
+func NewFakeActivities(fake *adapters.Fake, playbooks *playbook.Store) *Activities {
+	return &Activities{
+		Orders:    fake,
+		Transfers: fake,
+		Suppliers: fake,
+		Payments:  fake,
+		Inventory: fake,
+		Playbooks: playbooks,
+	}
+}
+
+// Context-gathering activities: one per source so the workflow can fetch them concurrently
+// and tolerate a single slow or failing system.
+
+// FetchOrder returns purchase details from the Order service.
+func (a *Activities) FetchOrder(ctx context.Context, orderID string) (modal.OrderDetails, error) {
+	order, err := a.Orders.GetOrder(ctx, orderID)
+	if err != nil {
+		return modal.OrderDetails{}, adapterError("get order", err)
+	}
+	fmt.Printf("[activity] FetchOrder order=%s\n", orderID)
+	return order, nil
+}
+
+// FetchTransfer returns the current transfer status, prior transfer attempts, the seats delivered so far and
+// when the venue unlocks transfers, if it has not yet.
+func (a *Activities) FetchTransfer(ctx context.Context, orderID string) (modal.TransferContext, error) {
+	status, err := a.Transfers.GetTransferStatus(ctx, orderID)
+	if err != nil {
+		return modal.TransferContext{}, adapterError("get transfer status", err)
+	}
+	attempts, err := a.Transfers.GetTransferAttempts(ctx, orderID)
+	if err != nil {
+		return modal.TransferContext{}, adapterError("get transfer attempts", err)
+	}
+	seats, err := a.Transfers.GetDeliveredSeats(ctx, orderID)
+	if err != nil {
+		return modal.TransferContext{}, adapterError("get delivered seats", err)
+	}
+	// The transfer window only matters for blocked transfers, so a failed lookup leaves BlockedUntil unset rather
+	// than losing the status and attempts the classifier needs.
+	blockedUntil, err := a.Transfers.GetTransferBlockedUntil(ctx, orderID)
+	if err != nil {
+		fmt.Printf("[activity] FetchTransfer order=%s transfer window unavailable, continuing without it: %v\n", orderID, err)
+		blockedUntil = nil
+	}
+	fmt.Printf("[activity] FetchTransfer order=%s status=%s attempts=%d seats=%d blocked=%t\n", orderID, status, len(attempts), len(seats), blockedUntil != nil)
+	return modal.TransferContext{Status: status, Attempts: attempts, DeliveredSeats: seats, BlockedUntil: blockedUntil}, nil
+}
+
+// FetchSupplierComms returns the supplier communication history.
+func (a *Activities) FetchSupplierComms(ctx context.Context, orderID string) ([]modal.SupplierMessage, error) {
+	comms, err := a.Suppliers.GetCommsHistory(ctx, orderID)
+	if err != nil {
+		return nil, adapterError("get supplier comms", err)
+	}
+	fmt.Printf("[activity] FetchSupplierComms order=%s messages=%d\n", orderID, len(comms))
+	return comms, nil
+}
+
+// FetchPayment returns the payment authorization and refund eligibility.
+func (a *Activities) FetchPayment(ctx context.Context, orderID string) (modal.PaymentContext, error) {
+	auth, err := a.Payments.GetAuthorization(ctx, orderID)
+	if err != nil {
+		return modal.PaymentContext{}, adapterError("get payment authorization", err)
+	}
+	refund, err := a.Payments.GetRefundEligibility(ctx, orderID)
+	if err != nil {
+		return modal.PaymentContext{}, adapterError("get refund eligibility", err)
+	}
+	fmt.Printf("[activity] FetchPayment order=%s status=%s\n", orderID, auth.Status)
+	return modal.PaymentContext{Authorization: auth, RefundEligibility: refund}, nil
+}
+
+// Side-effecting activities take the ledger entry the workflow recorded for this attempt and pass its
+// idempotency key to the adapter, so a Temporal activity retry never repeats the side effect.
+
+// RetryTransfer asks the Transfer service to re-send the tickets.
+func (a *Activities) RetryTransfer(ctx context.Context, req modal.ActionAttemp) (modal.TransferStatus, error) {
+	status, err := a.Transfers.RetryTransfer(ctx, req.OrderID, req.Attempt, req.IdempotencyKey)
+	if err != nil {
+		return "", adapterError("retry transfer", err)
+	}
+	fmt.Printf("[activity] RetryTransfer order=%s attempt=%d key=%s => %s\n", req.OrderID, req.Attempt, req.IdempotencyKey, status)
+	return status, nil
+}
+
+// ReauthorizePayment re-authorizes the buyer's payment. The adapter classifies declines as hard or soft.
+func (a *Activities) ReauthorizePayment(ctx context.Context, req modal.ActionAttemp) (modal.PaymentAuthResult, error) {
+	res, err := a.Payments.Reauthorize(ctx, req.OrderID, req.Attempt, req.IdempotencyKey)
+	if err != nil {
+		return modal.PaymentAuthResult{}, adapterError("reauthorize payment", err)
+	}
+	fmt.Printf("[activity] ReauthorizePayment order=%s attempt=%d key=%s => %s %s\n", req.OrderID, req.Attempt, req.IdempotencyKey, res.Status, res.DeclineCode)
+	return res, nil
+}
 
-func (a *Activities) BuildCaseFile(ctx context.Context, orderID string) (modal.CaseFile, error) {
-	// Prototype: mock context aggregation.
-	// In the real system, this would
-	// 1. Get order details from Order service (e.g. order amount, buyer/seller info, etc.)
-	// 2. Call adapters: Order, Transfer, Supplier, Payment, etc.
+// NotifyBuyer sends the buyer a templated notification (email/SMS).
+func (a *Activities) NotifyBuyer(ctx context.Context, req modal.ActionAttemp, template string) error {
+	if err := a.Orders.NotifyBuyer(ctx, req.OrderID, template, nil, req.IdempotencyKey); err != nil {
+		return adapterError("notify buyer", err)
+	}
+	fmt.Printf("[activity] NotifyBuyer order=%s template=%s key=%s\n", req.OrderID, template, req.IdempotencyKey)
+	return nil
+}
 
-	// For demo purposes, hardcode issue type based on orderID (e.g. if orderID contains "TRANSFER_FAILED", set that as issue type).
-	cf := modal.CaseFile{
-		OrderID:        orderID,
-		IssueType:      modal.IssueTransferFailed,
-		BuyerEmail:     "richardshi2342+buyer+test1@gmail.com",
-		TransferStatus: modal.TransferNotAccepted,
-		AttemptCount:   0,
-		GeneratedAt:    time.Now().UTC(),
+// NotifyExpectedDelivery tells the buyer when to expect the tickets, using the template's expectedDelivery
+// placeholder.
+func (a *Activities) NotifyExpectedDelivery(ctx context.Context, req modal.ActionAttemp, template string, expectedAt time.Time) error {
+	vars := map[string]string{"expectedDelivery": expectedAt.UTC().Format(time.RFC3339)}
+	if err := a.Orders.NotifyBuyer(ctx, req.OrderID, template, vars, req.IdempotencyKey); err != nil {
+		return adapterError("notify buyer", err)
 	}
-	fmt.Printf("[activity] built casefile for order=%s issue=%s\n", orderID, cf.IssueType)
-	return cf, nil
+	fmt.Printf("[activity] NotifyExpectedDelivery order=%s template=%s expected=%s key=%s\n", req.OrderID, template, vars["expectedDelivery"], req.IdempotencyKey)
+	return nil
 }
 
-// RetryTransfer simulates retrying a transfer.
-// For demo purposes:
-// - If orderID contains "FAIL" => never succeeds.
-// - Otherwise succeeds on attempt >= 2.
-func (a *Activities) RetryTransfer(ctx context.Context, orderID string, attempt int) (modal.TransferStatus, error) {
-	if strings.Contains(strings.ToUpper(orderID), "FAIL") {
-		fmt.Printf("[activity] RetryTransfer order=%s attempt=%d => NOT_ACCEPTED (forced)\n", orderID, attempt)
-		return modal.TransferNotAccepted, nil
+// PingSupplier sends the supplier a message about the order.
+func (a *Activities) PingSupplier(ctx context.Context, req modal.ActionAttemp, message string) error {
+	if err := a.Suppliers.SendPing(ctx, req.OrderID, message, req.IdempotencyKey); err != nil {
+		return adapterError("ping supplier", err)
 	}
+	fmt.Printf("[activity] PingSupplier order=%s key=%s\n", req.OrderID, req.IdempotencyKey)
+	return nil
+}
 
-	// Simulate success on 2nd attempt or later
-	if attempt >= 2 {
-		fmt.Printf("[activity] RetryTransfer order=%s attempt=%d => ACCEPTED\n", orderID, attempt)
+// RequestSeatCorrection asks the supplier to deliver the seats sold in place of the discrepancy. It returns
+// modal.CorrectionCorrected or modal.CorrectionDeclined.
+func (a *Activities) RequestSeatCorrection(ctx context.Context, req modal.ActionAttemp, d modal.SeatDiscrepancy) (string, error) {
+	status, err := a.Suppliers.RequestCorrection(ctx, req.OrderID, d, req.IdempotencyKey)
+	if err != nil {
+		return "", adapterError("request correction", err)
+	}
+	fmt.Printf("[activity] RequestSeatCorrection order=%s missing=%d unexpected=%d key=%s => %s\n", req.OrderID, len(d.Missing), len(d.Unexpected), req.IdempotencyKey, status)
+	return status, nil
+}
 
-		// In a real implementation, this would call the Transfer service adapter to perform the transfer and return the actual status.
-		return modal.TransferAccepted, nil
+// ComputeRefund asks the Payment service for the refund the policy allows. It has no side effects.
+func (a *Activities) ComputeRefund(ctx context.Context, orderID string) (modal.RefundProposal, error) {
+	p, err := a.Payments.ComputeRefund(ctx, orderID)
+	if err != nil {
+		return modal.RefundProposal{}, adapterError("compute refund", err)
 	}
+	fmt.Printf("[activity] ComputeRefund order=%s amount=%d %s policy=%s\n", orderID, p.AmountCents, p.Currency, p.Policy)
+	return p, nil
+}
 
-	// Simulate failure on first attempt
-	fmt.Printf("[activity] RetryTransfer order=%s attempt=%d => NOT_ACCEPTED\n", orderID, attempt)
-	return modal.TransferNotAccepted, nil
+// IssueRefund refunds the approved amount to the buyer.
+func (a *Activities) IssueRefund(ctx context.Context, req modal.ActionAttemp, proposal modal.RefundProposal) (modal.RefundResult, error) {
+	res, err := a.Payments.IssueRefund(ctx, req.OrderID, proposal.AmountCents, req.IdempotencyKey)
+	if err != nil {
+		return modal.RefundResult{}, adapterError("issue refund", err)
+	}
+	fmt.Printf("[activity] IssueRefund order=%s amount=%d key=%s => %s %s\n", req.OrderID, res.AmountCents, req.IdempotencyKey, res.RefundID, res.Status)
+	return res, nil
+}
+
+// FindReplacementSeats lists comparable seats for the order's event priced within band, cheapest first.
+// It has no side effects.
+func (a *Activities) FindReplacementSeats(ctx context.Context, orderID string, band modal.PriceBand) ([]modal.SeatListing, error) {
+	listings, err := a.Inventory.FindComparableSeats(ctx, orderID, band)
+	if err != nil {
+		return nil, adapterError("find comparable seats", err)
+	}
+	fmt.Printf("[activity] FindReplacementSeats order=%s band=%d-%d => %d listings\n", orderID, band.MinCents, band.MaxCents, len(listings))
+	return listings, nil
+}
+
+// ReserveSeats holds a replacement listing for the order.
+func (a *Activities) ReserveSeats(ctx context.Context, req modal.ActionAttemp, listing modal.SeatListing) (modal.Reservation, error) {
+	res, err := a.Inventory.ReserveSeats(ctx, req.OrderID, listing.ListingID, req.IdempotencyKey)
+	if err != nil {
+		return modal.Reservation{}, adapterError("reserve seats", err)
+	}
+	fmt.Printf("[activity] ReserveSeats order=%s listing=%s key=%s => %s %s\n", req.OrderID, listing.ListingID, req.IdempotencyKey, res.ReservationID, res.Status)
+	return res, nil
+}
+
+// PurchaseSeats buys the seats of a replacement reservation.
+func (a *Activities) PurchaseSeats(ctx context.Context, req modal.ActionAttemp, reservation modal.Reservation) (modal.Reservation, error) {
+	res, err := a.Inventory.PurchaseSeats(ctx, req.OrderID, reservation.ReservationID, req.IdempotencyKey)
+	if err != nil {
+		return modal.Reservation{}, adapterError("purchase seats", err)
+	}
+	fmt.Printf("[activity] PurchaseSeats order=%s reservation=%s key=%s => %s\n", req.OrderID, res.ReservationID, req.IdempotencyKey, res.Status)
+	return res, nil
+}
+
+// TransferReplacement sends the buyer the purchased replacement seats.
+func (a *Activities) TransferReplacement(ctx context.Context, req modal.ActionAttemp, reservation modal.Reservation) (modal.TransferStatus, error) {
+	status, err := a.Transfers.TransferReplacement(ctx, req.OrderID, reservation.ReservationID, req.IdempotencyKey)
+	if err != nil {
+		return "", adapterError("transfer replacement", err)
+	}
+	fmt.Printf("[activity] TransferReplacement order=%s reservation=%s key=%s => %s\n", req.OrderID, reservation.ReservationID, req.IdempotencyKey, status)
+	return status, nil
+}
+
+// Compensating activities undo a side effect when the workflow fails after it (see workflows/saga.go). They take
+// their own ledger entry plus the one of the side effect they undo.
+
+// CancelTransfer withdraws a re-sent transfer the buyer has not accepted.
+func (a *Activities) CancelTransfer(ctx context.Context, req modal.ActionAttemp, transfer modal.ActionAttemp) error {
+	if err := a.Transfers.CancelTransfer(ctx, req.OrderID, transfer.IdempotencyKey, req.IdempotencyKey); err != nil {
+		return adapterError("cancel transfer", err)
+	}
+	fmt.Printf("[activity] CancelTransfer order=%s transfer=%s key=%s\n", req.OrderID, transfer.IdempotencyKey, req.IdempotencyKey)
+	return nil
+}
+
+// ReleaseSeats gives up a replacement reservation.
+func (a *Activities) ReleaseSeats(ctx context.Context, req modal.ActionAttemp, reservation modal.Reservation) error {
+	if err := a.Inventory.ReleaseSeats(ctx, req.OrderID, reservation.ReservationID, req.IdempotencyKey); err != nil {
+		return adapterError("release seats", err)
+	}
+	fmt.Printf("[activity] ReleaseSeats order=%s reservation=%s key=%s\n", req.OrderID, reservation.ReservationID, req.IdempotencyKey)
+	return nil
+}
+
+// VoidRefund reverses an issued refund.
+func (a *Activities) VoidRefund(ctx context.Context, req modal.ActionAttemp, refund modal.RefundResult) (modal.RefundResult, error) {
+	res, err := a.Payments.VoidRefund(ctx, req.OrderID, refund.RefundID, req.IdempotencyKey)
+	if err != nil {
+		return modal.RefundResult{}, adapterError("void refund", err)
+	}
+	fmt.Printf("[activity] VoidRefund order=%s refund=%s key=%s => %s\n", req.OrderID, res.RefundID, req.IdempotencyKey, res.Status)
+	return res, nil
+}
+
+// ClassifyIssue decides the issue type, tier and confidence for a case file.
+func (a *Activities) ClassifyIssue(ctx context.Context, cf modal.CaseFile) (modal.Classification, error) {
+	c := a.Classifier
+	if c == nil {
+		c = classify.Rules{}
+	}
+	res, err := c.Classify(ctx, cf)
+	if err != nil {
+		return modal.Classification{}, fmt.Errorf("classify: %w", err)
+	}
+	fmt.Printf("[activity] ClassifyIssue order=%s => %s tier=%s confidence=%.2f (%s)\n", cf.OrderID, res.IssueType, res.Tier, res.Confidence, res.Classifier)
+	return res, nil
+}
+
+// LoadPlaybook returns the playbook configured for issueType.
+// It is an activity (rather than a direct read in the workflow) so the loaded playbook is recorded
+// in workflow history and replays deterministically even if the config changes later.
+// An empty playbook (no steps) means the issue type is not supported.
+func (a *Activities) LoadPlaybook(ctx context.Context, issueType modal.IssueType) (playbook.Playbook, error) {
+	store := a.Playbooks
+	if store == nil {
+		store = playbook.NewStore("")
+	}
+	p, ok, err := store.Get(issueType)
+	if err != nil {
+		return playbook.Playbook{}, err
+	}
+	if !ok {
+		fmt.Printf("[activity] no playbook for issue=%s\n", issueType)
+		return playbook.Playbook{}, nil
+	}
+	fmt.Printf("[activity] loaded playbook issue=%s version=%d steps=%d\n", issueType, p.Version, len(p.Steps))
+	return p, nil
+}
+
+// adapterError wraps a downstream error. Client errors (4xx) will not succeed on retry,
+// so they are marked non-retryable to stop Temporal from hammering the service.
+func adapterError(op string, err error) error {
+	var httpErr *adapters.HTTPError
+	if errors.As(err, &httpErr) && !httpErr.Retryable() {
+		return temporal.NewNonRetryableApplicationError(op+": "+err.Error(), "AdapterError", err)
+	}
+	return fmt.Errorf("%s: %w", op, err)
 }
@@ -0,0 +1,100 @@
+package adapters
+
+import (
+	"broken-order-service/internal/modal"
+	"context"
+	"time"
+)
+
+// Adapters are the boundary between activities and downstream systems.
+// Activities only talk to these interfaces, so each environment can plug in
+// in-memory fakes (local dev/tests) or HTTP clients (real services).
+//
+// Side-effecting methods take an idempotency key (see modal.ActionAttemp). Implementations must
+// return the original result, without repeating the side effect, when a key is seen again.
+
+// OrderAdapter reads purchase details and reaches the buyer.
+type OrderAdapter interface {
+	GetOrder(ctx context.Context, orderID string) (modal.OrderDetails, error)
+	// NotifyBuyer sends the buyer a templated notification. vars fill the template's placeholders and may be nil.
+	NotifyBuyer(ctx context.Context, orderID, template string, vars map[string]string, idempotencyKey string) error
+}
+
+// TransferAdapter reads, retries and cancels ticket transfers, and transfers purchased replacement seats.
+type TransferAdapter interface {
+	GetTransferStatus(ctx context.Context, orderID string) (modal.TransferStatus, error)
+	GetTransferAttempts(ctx context.Context, orderID string) ([]modal.ActionAttemp, error)
+	// GetDeliveredSeats lists the seats transferred to the buyer so far.
+	GetDeliveredSeats(ctx context.Context, orderID string) ([]modal.Seat, error)
+	// GetTransferBlockedUntil returns when the venue starts allowing transfers for the order's event, or nil if
+	// transfers are not blocked.
+	GetTransferBlockedUntil(ctx context.Context, orderID string) (*time.Time, error)
+	RetryTransfer(ctx context.Context, orderID string, attempt int, idempotencyKey string) (modal.TransferStatus, error)
+	// CancelTransfer withdraws the transfer re-sent by the RetryTransfer call made with transferKey.
+	// A transfer the buyer has already accepted cannot be cancelled.
+	CancelTransfer(ctx context.Context, orderID, transferKey, idempotencyKey string) error
+	// TransferReplacement sends the buyer the seats of a purchased reservation. Like a re-sent transfer, it can
+	// be withdrawn with CancelTransfer until the buyer accepts it.
+	TransferReplacement(ctx context.Context, orderID, reservationID, idempotencyKey string) (modal.TransferStatus, error)
+}
+
+// SupplierAdapter reads supplier comms, pings the supplier and asks it to correct a delivery.
+type SupplierAdapter interface {
+	GetCommsHistory(ctx context.Context, orderID string) ([]modal.SupplierMessage, error)
+	SendPing(ctx context.Context, orderID, message, idempotencyKey string) error
+	// RequestCorrection asks the supplier to deliver the seats sold in place of what the discrepancy describes.
+	// It returns modal.CorrectionCorrected once the right seats were transferred, or modal.CorrectionDeclined.
+	RequestCorrection(ctx context.Context, orderID string, d modal.SeatDiscrepancy, idempotencyKey string) (string, error)
+}
+
+// PaymentAdapter reads and re-authorizes the buyer's payment, reports refund policy and issues and voids refunds.
+type PaymentAdapter interface {
+	GetAuthorization(ctx context.Context, orderID string) (modal.PaymentAuthResult, error)
+	GetRefundEligibility(ctx context.Context, orderID string) (modal.RefundEligibility, error)
+	Reauthorize(ctx context.Context, orderID string, attempt int, idempotencyKey string) (modal.PaymentAuthResult, error)
+	// ComputeRefund proposes a refund under the current policy; it has no side effects.
+	ComputeRefund(ctx context.Context, orderID string) (modal.RefundProposal, error)
+	IssueRefund(ctx context.Context, orderID string, amountCents int64, idempotencyKey string) (modal.RefundResult, error)
+	// VoidRefund reverses an issued refund; a voided refund no longer counts towards the refunded amount.
+	VoidRefund(ctx context.Context, orderID, refundID, idempotencyKey string) (modal.RefundResult, error)
+}
+
+// InventoryAdapter searches the marketplace for replacement seats, holds them for an order and buys them.
+type InventoryAdapter interface {
+	// FindComparableSeats lists other listings for the order's event with at least as many seats and a price
+	// within band, cheapest first.
+	FindComparableSeats(ctx context.Context, orderID string, band modal.PriceBand) ([]modal.SeatListing, error)
+	// ReserveSeats holds a listing for the order. A listing already held for another order cannot be reserved.
+	ReserveSeats(ctx context.Context, orderID, listingID, idempotencyKey string) (modal.Reservation, error)
+	// ReleaseSeats gives up a reservation so the listing can be sold again.
+	ReleaseSeats(ctx context.Context, orderID, reservationID, idempotencyKey string) error
+	// PurchaseSeats buys the seats of a held reservation. A purchase cannot be undone, and a purchased
+	// reservation can no longer be released.
+	PurchaseSeats(ctx context.Context, orderID, reservationID, idempotencyKey string) (modal.Reservation, error)
+}
+
+// hardDeclineCodes are issuer decline codes that will not succeed on retry.
+// Anything else is treated as a soft (transient) decline.
+var hardDeclineCodes = map[string]bool{
+	"stolen_card":      true,
+	"lost_card":        true,
+	"fraudulent":       true,
+	"account_closed":   true,
+	"invalid_account":  true,
+	"pickup_card":      true,
+	"restricted_card":  true,
+	"do_not_try_again": true,
+}
+
+// AuthResult builds a PaymentAuthResult from a processor decline code ("" means authorized),
+// classifying the decline as hard or soft. Every PaymentAdapter uses it so classification is consistent.
+func AuthResult(declineCode string) modal.PaymentAuthResult {
+	switch {
+	case declineCode == "":
+		return modal.PaymentAuthResult{Status: modal.PaymentAuthorized}
+	case hardDeclineCodes[declineCode]:
+		return modal.PaymentAuthResult{Status: modal.PaymentHardDeclined, DeclineCode: declineCode}
+	default:
+		return modal.PaymentAuthResult{Status: modal.PaymentSoftDeclined, DeclineCode: declineCode}
+	}
+}
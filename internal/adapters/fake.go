@@ -0,0 +1,680 @@
+package adapters
+
+import (
+	"broken-order-service/internal/modal"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBuyerEmail      = "richardshi2342+buyer+test1@gmail.com"
+	defaultAcceptOnAttempt = 2
+)
+
+// Operations that a scenario can inject faults into.
+const (
+	OpGetOrder                = "getOrder"
+	OpNotifyBuyer             = "notifyBuyer"
+	OpGetTransfer             = "getTransfer"
+	OpGetTransferAttempts     = "getTransferAttempts"
+	OpGetDeliveredSeats       = "getDeliveredSeats"
+	OpGetTransferBlockedUntil = "getTransferBlockedUntil"
+	OpRetryTransfer           = "retryTransfer"
+	OpCancelTransfer          = "cancelTransfer"
+	OpTransferReplacement     = "transferReplacement"
+	OpGetComms                = "getComms"
+	OpSendPing                = "sendPing"
+	OpRequestCorrection       = "requestCorrection"
+	OpGetAuthorization        = "getAuthorization"
+	OpGetRefundEligibility    = "getRefundEligibility"
+	OpReauthorize             = "reauthorize"
+	OpComputeRefund           = "computeRefund"
+	OpIssueRefund             = "issueRefund"
+	OpVoidRefund              = "voidRefund"
+	OpFindSeats               = "findSeats"
+	OpReserveSeats            = "reserveSeats"
+	OpReleaseSeats            = "releaseSeats"
+	OpPurchaseSeats           = "purchaseSeats"
+)
+
+var knownOps = map[string]bool{
+	OpGetOrder:                true,
+	OpNotifyBuyer:             true,
+	OpGetTransfer:             true,
+	OpGetTransferAttempts:     true,
+	OpGetDeliveredSeats:       true,
+	OpGetTransferBlockedUntil: true,
+	OpRetryTransfer:           true,
+	OpCancelTransfer:          true,
+	OpTransferReplacement:     true,
+	OpGetComms:                true,
+	OpSendPing:                true,
+	OpRequestCorrection:       true,
+	OpGetAuthorization:        true,
+	OpGetRefundEligibility:    true,
+	OpReauthorize:             true,
+	OpComputeRefund:           true,
+	OpIssueRefund:             true,
+	OpVoidRefund:              true,
+	OpFindSeats:               true,
+	OpReserveSeats:            true,
+	OpReleaseSeats:            true,
+	OpPurchaseSeats:           true,
+}
+
+// FakeOrder is the in-memory state of one order across all fake downstream systems.
+type FakeOrder struct {
+	Order          modal.OrderDetails   `json:"order"`
+	TransferStatus modal.TransferStatus `json:"transferStatus"`
+	// AcceptTransferOnAttempt is the first RetryTransfer attempt that succeeds. 0 means never.
+	AcceptTransferOnAttempt int                  `json:"acceptTransferOnAttempt"`
+	TransferAttempts        []modal.ActionAttemp `json:"transferAttempts,omitempty"`
+	// DeliveredSeats are the seats the buyer received. Nil means the order's seats once the transfer is accepted.
+	DeliveredSeats []modal.Seat `json:"deliveredSeats,omitempty"`
+	// TransferBlockedUntil is when the venue unlocks transfers. RetryTransfer is rejected with a 409 until then.
+	TransferBlockedUntil *time.Time `json:"transferBlockedUntil,omitempty"`
+	// DeclineCodes is the processor response per authorization attempt ("" = authorized).
+	// Index 0 is the current authorization; the last entry repeats for later attempts.
+	DeclineCodes []string `json:"declineCodes,omitempty"`
+	// Refund overrides the computed refund eligibility when set.
+	Refund *modal.RefundEligibility `json:"refund,omitempty"`
+	Comms  []modal.SupplierMessage  `json:"comms,omitempty"`
+	// SupplierCorrects makes RequestCorrection deliver the order's seats instead of declining.
+	SupplierCorrects bool                 `json:"supplierCorrects,omitempty"`
+	Notified         []string             `json:"notified,omitempty"`
+	Refunds          []modal.RefundResult `json:"refunds,omitempty"`
+	// Inventory is the replacement listings on sale for the order's event.
+	Inventory    []modal.SeatListing `json:"inventory,omitempty"`
+	Reservations []modal.Reservation `json:"reservations,omitempty"`
+}
+
+// Fake implements every adapter interface against in-memory state.
+// Orders that were not added explicitly are created on first use from the scenario
+// (or, without one, with transfers accepted on attempt 2 and payments authorized).
+type Fake struct {
+	mu       sync.Mutex
+	scenario *Scenario
+	orders   map[string]*FakeOrder
+	// faults holds the remaining injected HTTP status codes per order and operation.
+	faults map[string]map[string][]int
+	// done holds the result of every side effect that succeeded, by idempotency key.
+	done map[string]any
+	// clock is the fake's current time. Nil means the wall clock.
+	clock func() time.Time
+}
+
+var (
+	_ OrderAdapter     = (*Fake)(nil)
+	_ TransferAdapter  = (*Fake)(nil)
+	_ SupplierAdapter  = (*Fake)(nil)
+	_ PaymentAdapter   = (*Fake)(nil)
+	_ InventoryAdapter = (*Fake)(nil)
+)
+
+func NewFake() *Fake {
+	return NewFakeFromScenario(&Scenario{})
+}
+
+func NewFakeFromScenario(s *Scenario) *Fake {
+	return &Fake{
+		scenario: s,
+		orders:   make(map[string]*FakeOrder),
+		faults:   make(map[string]map[string][]int),
+		done:     make(map[string]any),
+	}
+}
+
+// SetClock makes the fake read the time from now instead of the wall clock, e.g. the test environment's clock so
+// that a workflow sleeping until a transfer unlocks finds it unlocked.
+func (f *Fake) SetClock(now func() time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clock = now
+}
+
+// now returns the fake's current time in UTC. Caller must hold mu.
+func (f *Fake) now() time.Time {
+	if f.clock != nil {
+		return f.clock().UTC()
+	}
+	return time.Now().UTC()
+}
+
+// Put adds or replaces the state for an order.
+func (f *Fake) Put(o FakeOrder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cp := o
+	f.orders[o.Order.OrderID] = &cp
+}
+
+// Get returns a copy of the current state for an order.
+func (f *Fake) Get(orderID string) FakeOrder {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return *f.order(orderID)
+}
+
+// order returns the state for orderID, creating it from the scenario if needed. Caller must hold mu.
+func (f *Fake) order(orderID string) *FakeOrder {
+	if o, ok := f.orders[orderID]; ok {
+		return o
+	}
+	o, faults := f.scenario.fakeOrder(orderID)
+	f.orders[orderID] = &o
+	f.faults[orderID] = faults
+	return &o
+}
+
+// fault consumes the next injected status code for (orderID, op), if any. Caller must hold mu.
+func (f *Fake) fault(orderID, op string) error {
+	f.order(orderID)
+	codes := f.faults[orderID][op]
+	if len(codes) == 0 {
+		return nil
+	}
+	code := codes[0]
+	f.faults[orderID][op] = codes[1:]
+	if code >= 200 && code <= 299 {
+		return nil
+	}
+	return &HTTPError{Method: "FAKE", URL: op, StatusCode: code, Body: "fault injected by scenario: " + http.StatusText(code)}
+}
+
+// replay returns the recorded result for a side effect that already succeeded under key. Caller must hold mu.
+func (f *Fake) replay(key string) (any, bool) {
+	if key == "" {
+		return nil, false
+	}
+	v, ok := f.done[key]
+	return v, ok
+}
+
+// record remembers the result of a successful side effect under key. Caller must hold mu.
+func (f *Fake) record(key string, v any) {
+	if key != "" {
+		f.done[key] = v
+	}
+}
+
+func (f *Fake) GetOrder(ctx context.Context, orderID string) (modal.OrderDetails, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fault(orderID, OpGetOrder); err != nil {
+		return modal.OrderDetails{}, err
+	}
+	return f.order(orderID).Order, nil
+}
+
+func (f *Fake) NotifyBuyer(ctx context.Context, orderID, template string, vars map[string]string, idempotencyKey string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.replay(idempotencyKey); ok {
+		return nil
+	}
+	if err := f.fault(orderID, OpNotifyBuyer); err != nil {
+		return err
+	}
+	o := f.order(orderID)
+	o.Notified = append(o.Notified, template)
+	f.record(idempotencyKey, nil)
+	return nil
+}
+
+func (f *Fake) GetTransferStatus(ctx context.Context, orderID string) (modal.TransferStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fault(orderID, OpGetTransfer); err != nil {
+		return "", err
+	}
+	return f.order(orderID).TransferStatus, nil
+}
+
+func (f *Fake) RetryTransfer(ctx context.Context, orderID string, attempt int, idempotencyKey string) (modal.TransferStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, ok := f.replay(idempotencyKey); ok {
+		return v.(modal.TransferStatus), nil
+	}
+	if err := f.fault(orderID, OpRetryTransfer); err != nil {
+		return "", err
+	}
+	o := f.order(orderID)
+	if until := o.TransferBlockedUntil; until != nil && f.now().Before(*until) {
+		return "", &HTTPError{Method: "FAKE", URL: OpRetryTransfer, StatusCode: http.StatusConflict,
+			Body: "venue blocks transfers until " + until.Format(time.RFC3339)}
+	}
+	if o.AcceptTransferOnAttempt > 0 && attempt >= o.AcceptTransferOnAttempt {
+		o.TransferStatus = modal.TransferAccepted
+	}
+	o.TransferAttempts = append(o.TransferAttempts, modal.ActionAttemp{
+		AttemptID:      fmt.Sprintf("%s-transfer-%d", orderID, len(o.TransferAttempts)+1),
+		OrderID:        orderID,
+		ActionType:     "RETRY_TRANSFER",
+		IdempotencyKey: idempotencyKey,
+		AttemptedAt:    f.now(),
+		Result:         string(o.TransferStatus),
+	})
+	f.record(idempotencyKey, o.TransferStatus)
+	return o.TransferStatus, nil
+}
+
+// CancelTransfer marks the transfer re-sent with transferKey as CANCELLED.
+func (f *Fake) CancelTransfer(ctx context.Context, orderID, transferKey, idempotencyKey string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.replay(idempotencyKey); ok {
+		return nil
+	}
+	if err := f.fault(orderID, OpCancelTransfer); err != nil {
+		return err
+	}
+	o := f.order(orderID)
+	for i := range o.TransferAttempts {
+		a := &o.TransferAttempts[i]
+		if a.IdempotencyKey != transferKey {
+			continue
+		}
+		if a.Result == string(modal.TransferAccepted) {
+			return &HTTPError{Method: "FAKE", URL: OpCancelTransfer, StatusCode: http.StatusConflict,
+				Body: fmt.Sprintf("transfer %s was already accepted", transferKey)}
+		}
+		a.Result = string(modal.TransferCancelled)
+		f.record(idempotencyKey, nil)
+		return nil
+	}
+	return &HTTPError{Method: "FAKE", URL: OpCancelTransfer, StatusCode: http.StatusNotFound,
+		Body: fmt.Sprintf("no transfer sent with key %s", transferKey)}
+}
+
+// TransferReplacement records a transfer of the purchased reservation's seats as a transfer attempt, so
+// CancelTransfer can withdraw it. The buyer accepts it at once unless the order never accepts transfers.
+func (f *Fake) TransferReplacement(ctx context.Context, orderID, reservationID, idempotencyKey string) (modal.TransferStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, ok := f.replay(idempotencyKey); ok {
+		return v.(modal.TransferStatus), nil
+	}
+	if err := f.fault(orderID, OpTransferReplacement); err != nil {
+		return "", err
+	}
+	o := f.order(orderID)
+	i := slices.IndexFunc(o.Reservations, func(r modal.Reservation) bool { return r.ReservationID == reservationID })
+	switch {
+	case i < 0:
+		return "", &HTTPError{Method: "FAKE", URL: OpTransferReplacement, StatusCode: http.StatusNotFound,
+			Body: fmt.Sprintf("no reservation %s", reservationID)}
+	case o.Reservations[i].Status != modal.ReservationPurchased:
+		return "", &HTTPError{Method: "FAKE", URL: OpTransferReplacement, StatusCode: http.StatusConflict,
+			Body: fmt.Sprintf("reservation %s has not been purchased", reservationID)}
+	}
+	o.TransferStatus = modal.TransferNotAccepted
+	if o.AcceptTransferOnAttempt > 0 {
+		o.TransferStatus = modal.TransferAccepted
+	}
+	o.TransferAttempts = append(o.TransferAttempts, modal.ActionAttemp{
+		AttemptID:      fmt.Sprintf("%s-transfer-%d", orderID, len(o.TransferAttempts)+1),
+		OrderID:        orderID,
+		ActionType:     "TRANSFER_REPLACEMENT",
+		IdempotencyKey: idempotencyKey,
+		AttemptedAt:    f.now(),
+		Result:         string(o.TransferStatus),
+	})
+	f.record(idempotencyKey, o.TransferStatus)
+	return o.TransferStatus, nil
+}
+
+func (f *Fake) GetTransferAttempts(ctx context.Context, orderID string) ([]modal.ActionAttemp, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fault(orderID, OpGetTransferAttempts); err != nil {
+		return nil, err
+	}
+	return append([]modal.ActionAttemp(nil), f.order(orderID).TransferAttempts...), nil
+}
+
+func (f *Fake) GetDeliveredSeats(ctx context.Context, orderID string) ([]modal.Seat, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fault(orderID, OpGetDeliveredSeats); err != nil {
+		return nil, err
+	}
+	return slices.Clone(delivered(f.order(orderID))), nil
+}
+
+// GetTransferBlockedUntil returns the unlock time until it has passed.
+func (f *Fake) GetTransferBlockedUntil(ctx context.Context, orderID string) (*time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fault(orderID, OpGetTransferBlockedUntil); err != nil {
+		return nil, err
+	}
+	until := f.order(orderID).TransferBlockedUntil
+	if until == nil || !f.now().Before(*until) {
+		return nil, nil
+	}
+	t := *until
+	return &t, nil
+}
+
+// delivered returns the seats the buyer received.
+func delivered(o *FakeOrder) []modal.Seat {
+	if o.DeliveredSeats == nil && o.TransferStatus == modal.TransferAccepted {
+		return o.Order.Seats
+	}
+	return o.DeliveredSeats
+}
+
+func (f *Fake) GetCommsHistory(ctx context.Context, orderID string) ([]modal.SupplierMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fault(orderID, OpGetComms); err != nil {
+		return nil, err
+	}
+	return append([]modal.SupplierMessage(nil), f.order(orderID).Comms...), nil
+}
+
+func (f *Fake) SendPing(ctx context.Context, orderID, message, idempotencyKey string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.replay(idempotencyKey); ok {
+		return nil
+	}
+	if err := f.fault(orderID, OpSendPing); err != nil {
+		return err
+	}
+	o := f.order(orderID)
+	o.Comms = append(o.Comms, modal.SupplierMessage{
+		At:        f.now(),
+		Direction: "OUTBOUND",
+		Body:      message,
+	})
+	f.record(idempotencyKey, nil)
+	return nil
+}
+
+// RequestCorrection delivers the order's seats if the scenario lets the supplier correct it, and records the
+// supplier's answer in the comms history either way.
+func (f *Fake) RequestCorrection(ctx context.Context, orderID string, d modal.SeatDiscrepancy, idempotencyKey string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, ok := f.replay(idempotencyKey); ok {
+		return v.(string), nil
+	}
+	if err := f.fault(orderID, OpRequestCorrection); err != nil {
+		return "", err
+	}
+	o := f.order(orderID)
+	status, body := modal.CorrectionDeclined, fmt.Sprintf("Cannot correct the delivery; %d seats affected.", len(d.Missing))
+	if o.SupplierCorrects {
+		o.DeliveredSeats = slices.Clone(o.Order.Seats)
+		o.TransferStatus = modal.TransferAccepted
+		status, body = modal.CorrectionCorrected, "Correct seats transferred to the buyer."
+	}
+	o.Comms = append(o.Comms, modal.SupplierMessage{At: f.now(), Direction: "INBOUND", Body: body})
+	f.record(idempotencyKey, status)
+	return status, nil
+}
+
+func (f *Fake) GetAuthorization(ctx context.Context, orderID string) (modal.PaymentAuthResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fault(orderID, OpGetAuthorization); err != nil {
+		return modal.PaymentAuthResult{}, err
+	}
+	return AuthResult(declineCodeAt(f.order(orderID).DeclineCodes, 0)), nil
+}
+
+// GetRefundEligibility applies a simple policy: full refund while tickets are undelivered and the event
+// has not started. A scenario can override it per order.
+func (f *Fake) GetRefundEligibility(ctx context.Context, orderID string) (modal.RefundEligibility, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fault(orderID, OpGetRefundEligibility); err != nil {
+		return modal.RefundEligibility{}, err
+	}
+	return f.refundEligibility(f.order(orderID)), nil
+}
+
+func (f *Fake) refundEligibility(o *FakeOrder) modal.RefundEligibility {
+	switch {
+	case o.Refund != nil:
+		return *o.Refund
+	case !o.Order.EventDate.IsZero() && f.now().After(o.Order.EventDate):
+		return modal.RefundEligibility{Policy: "EVENT_PASSED", Reason: "event has already started"}
+	case o.TransferStatus == modal.TransferAccepted && len(modal.CompareSeats(o.Order.Seats, delivered(o)).Missing) == 0:
+		return modal.RefundEligibility{Policy: "TICKETS_DELIVERED", Reason: "buyer accepted the transfer"}
+	default:
+		return modal.RefundEligibility{
+			Eligible:       true,
+			MaxRefundCents: o.Order.AmountCents,
+			Policy:         "FULL_REFUND_UNDELIVERED",
+			Reason:         "tickets not delivered before the event",
+		}
+	}
+}
+
+// ComputeRefund proposes the maximum refund the policy allows, less anything already refunded.
+func (f *Fake) ComputeRefund(ctx context.Context, orderID string) (modal.RefundProposal, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fault(orderID, OpComputeRefund); err != nil {
+		return modal.RefundProposal{}, err
+	}
+	o := f.order(orderID)
+	e := f.refundEligibility(o)
+	p := modal.RefundProposal{Currency: o.Order.Currency, Policy: e.Policy, Rationale: e.Reason}
+	if !e.Eligible {
+		return p, nil
+	}
+	p.AmountCents = e.MaxRefundCents - refunded(o)
+	if p.AmountCents < 0 {
+		p.AmountCents = 0
+	}
+	p.Rationale = fmt.Sprintf("%s: refund %s of %s paid", e.Reason,
+		modal.FormatAmount(p.AmountCents, p.Currency), modal.FormatAmount(o.Order.AmountCents, o.Order.Currency))
+	return p, nil
+}
+
+func (f *Fake) IssueRefund(ctx context.Context, orderID string, amountCents int64, idempotencyKey string) (modal.RefundResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, ok := f.replay(idempotencyKey); ok {
+		return v.(modal.RefundResult), nil
+	}
+	if err := f.fault(orderID, OpIssueRefund); err != nil {
+		return modal.RefundResult{}, err
+	}
+	o := f.order(orderID)
+	if amountCents <= 0 || refunded(o)+amountCents > o.Order.AmountCents {
+		return modal.RefundResult{}, &HTTPError{Method: "FAKE", URL: OpIssueRefund, StatusCode: http.StatusUnprocessableEntity,
+			Body: fmt.Sprintf("refund of %d exceeds refundable amount", amountCents)}
+	}
+	res := modal.RefundResult{
+		RefundID:    fmt.Sprintf("%s-refund-%d", orderID, len(o.Refunds)+1),
+		AmountCents: amountCents,
+		Currency:    o.Order.Currency,
+		Status:      modal.RefundIssued,
+	}
+	o.Refunds = append(o.Refunds, res)
+	f.record(idempotencyKey, res)
+	return res, nil
+}
+
+// VoidRefund marks an issued refund VOIDED, which makes its amount refundable again.
+func (f *Fake) VoidRefund(ctx context.Context, orderID, refundID, idempotencyKey string) (modal.RefundResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, ok := f.replay(idempotencyKey); ok {
+		return v.(modal.RefundResult), nil
+	}
+	if err := f.fault(orderID, OpVoidRefund); err != nil {
+		return modal.RefundResult{}, err
+	}
+	o := f.order(orderID)
+	for i := range o.Refunds {
+		if o.Refunds[i].RefundID == refundID {
+			o.Refunds[i].Status = modal.RefundVoided
+			f.record(idempotencyKey, o.Refunds[i])
+			return o.Refunds[i], nil
+		}
+	}
+	return modal.RefundResult{}, &HTTPError{Method: "FAKE", URL: OpVoidRefund, StatusCode: http.StatusNotFound,
+		Body: fmt.Sprintf("no refund %s", refundID)}
+}
+
+// refunded is the total of the order's refunds that have not been voided.
+func refunded(o *FakeOrder) int64 {
+	var total int64
+	for _, r := range o.Refunds {
+		if r.Status != modal.RefundVoided {
+			total += r.AmountCents
+		}
+	}
+	return total
+}
+
+func (f *Fake) Reauthorize(ctx context.Context, orderID string, attempt int, idempotencyKey string) (modal.PaymentAuthResult, error) {
+	if attempt < 1 {
+		return modal.PaymentAuthResult{}, &HTTPError{Method: "FAKE", URL: OpReauthorize, StatusCode: http.StatusBadRequest, Body: fmt.Sprintf("attempt must be >= 1, got %d", attempt)}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, ok := f.replay(idempotencyKey); ok {
+		return v.(modal.PaymentAuthResult), nil
+	}
+	if err := f.fault(orderID, OpReauthorize); err != nil {
+		return modal.PaymentAuthResult{}, err
+	}
+	res := AuthResult(declineCodeAt(f.order(orderID).DeclineCodes, attempt))
+	f.record(idempotencyKey, res)
+	return res, nil
+}
+
+func declineCodeAt(codes []string, i int) string {
+	if len(codes) == 0 {
+		return ""
+	}
+	if i >= len(codes) {
+		i = len(codes) - 1
+	}
+	return codes[i]
+}
+
+// FindComparableSeats returns the order's inventory listings within band that are not held and have enough
+// seats, cheapest first.
+func (f *Fake) FindComparableSeats(ctx context.Context, orderID string, band modal.PriceBand) ([]modal.SeatListing, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fault(orderID, OpFindSeats); err != nil {
+		return nil, err
+	}
+	o := f.order(orderID)
+	var listings []modal.SeatListing
+	for _, l := range o.Inventory {
+		if l.ListingID != o.Order.ListingID && len(l.Seats) >= len(o.Order.Seats) && band.Contains(l.PriceCents) &&
+			!held(o, l.ListingID) {
+			listings = append(listings, l)
+		}
+	}
+	sort.SliceStable(listings, func(i, j int) bool { return listings[i].PriceCents < listings[j].PriceCents })
+	return listings, nil
+}
+
+func (f *Fake) ReserveSeats(ctx context.Context, orderID, listingID, idempotencyKey string) (modal.Reservation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, ok := f.replay(idempotencyKey); ok {
+		return v.(modal.Reservation), nil
+	}
+	if err := f.fault(orderID, OpReserveSeats); err != nil {
+		return modal.Reservation{}, err
+	}
+	o := f.order(orderID)
+	i := slices.IndexFunc(o.Inventory, func(l modal.SeatListing) bool { return l.ListingID == listingID })
+	switch {
+	case i < 0:
+		return modal.Reservation{}, &HTTPError{Method: "FAKE", URL: OpReserveSeats, StatusCode: http.StatusNotFound,
+			Body: fmt.Sprintf("no listing %s", listingID)}
+	case held(o, listingID):
+		return modal.Reservation{}, &HTTPError{Method: "FAKE", URL: OpReserveSeats, StatusCode: http.StatusConflict,
+			Body: fmt.Sprintf("listing %s is already reserved", listingID)}
+	}
+	res := modal.Reservation{
+		ReservationID: fmt.Sprintf("%s-reservation-%d", orderID, len(o.Reservations)+1),
+		OrderID:       orderID,
+		Listing:       o.Inventory[i],
+		Status:        modal.ReservationHeld,
+		ExpiresAt:     f.now().Add(24 * time.Hour),
+	}
+	o.Reservations = append(o.Reservations, res)
+	f.record(idempotencyKey, res)
+	return res, nil
+}
+
+func (f *Fake) ReleaseSeats(ctx context.Context, orderID, reservationID, idempotencyKey string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.replay(idempotencyKey); ok {
+		return nil
+	}
+	if err := f.fault(orderID, OpReleaseSeats); err != nil {
+		return err
+	}
+	o := f.order(orderID)
+	for i := range o.Reservations {
+		r := &o.Reservations[i]
+		if r.ReservationID != reservationID {
+			continue
+		}
+		if r.Status == modal.ReservationPurchased {
+			return &HTTPError{Method: "FAKE", URL: OpReleaseSeats, StatusCode: http.StatusConflict,
+				Body: fmt.Sprintf("reservation %s was already purchased", reservationID)}
+		}
+		r.Status = modal.ReservationReleased
+		f.record(idempotencyKey, nil)
+		return nil
+	}
+	return &HTTPError{Method: "FAKE", URL: OpReleaseSeats, StatusCode: http.StatusNotFound,
+		Body: fmt.Sprintf("no reservation %s", reservationID)}
+}
+
+// PurchaseSeats marks a HELD reservation PURCHASED.
+func (f *Fake) PurchaseSeats(ctx context.Context, orderID, reservationID, idempotencyKey string) (modal.Reservation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, ok := f.replay(idempotencyKey); ok {
+		return v.(modal.Reservation), nil
+	}
+	if err := f.fault(orderID, OpPurchaseSeats); err != nil {
+		return modal.Reservation{}, err
+	}
+	o := f.order(orderID)
+	for i := range o.Reservations {
+		r := &o.Reservations[i]
+		if r.ReservationID != reservationID {
+			continue
+		}
+		if r.Status != modal.ReservationHeld {
+			return modal.Reservation{}, &HTTPError{Method: "FAKE", URL: OpPurchaseSeats, StatusCode: http.StatusConflict,
+				Body: fmt.Sprintf("reservation %s is %s", reservationID, r.Status)}
+		}
+		r.Status = modal.ReservationPurchased
+		f.record(idempotencyKey, *r)
+		return *r, nil
+	}
+	return modal.Reservation{}, &HTTPError{Method: "FAKE", URL: OpPurchaseSeats, StatusCode: http.StatusNotFound,
+		Body: fmt.Sprintf("no reservation %s", reservationID)}
+}
+
+// held reports whether listingID has a reservation that was not released.
+func held(o *FakeOrder, listingID string) bool {
+	return slices.ContainsFunc(o.Reservations, func(r modal.Reservation) bool {
+		return r.Listing.ListingID == listingID && r.Status == modal.ReservationHeld
+	})
+}
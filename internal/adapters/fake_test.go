@@ -0,0 +1,50 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"broken-order-service/internal/modal"
+)
+
+func TestRetryTransferRejectedUntilUnlock(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	unlock := now.Add(48 * time.Hour)
+
+	f := NewFake()
+	f.SetClock(func() time.Time { return now })
+	f.Put(FakeOrder{
+		Order:                   modal.OrderDetails{OrderID: "ORDER-BLOCKED-1"},
+		TransferStatus:          modal.TransferNotAccepted,
+		AcceptTransferOnAttempt: 1,
+		TransferBlockedUntil:    &unlock,
+	})
+
+	_, err := f.RetryTransfer(ctx, "ORDER-BLOCKED-1", 1, "early")
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusConflict {
+		t.Fatalf("retry before the unlock: got %v, want a 409", err)
+	}
+	if httpErr.Retryable() {
+		t.Error("a blocked transfer is reported as retryable")
+	}
+	if o := f.Get("ORDER-BLOCKED-1"); len(o.TransferAttempts) != 0 || o.TransferStatus != modal.TransferNotAccepted {
+		t.Errorf("rejected retry changed the transfer: %+v", o)
+	}
+	if got, err := f.GetTransferBlockedUntil(ctx, "ORDER-BLOCKED-1"); err != nil || got == nil || !got.Equal(unlock) {
+		t.Errorf("GetTransferBlockedUntil = %v, %v; want %s", got, err, unlock)
+	}
+
+	now = unlock
+	status, err := f.RetryTransfer(ctx, "ORDER-BLOCKED-1", 1, "after-unlock")
+	if err != nil || status != modal.TransferAccepted {
+		t.Fatalf("retry after the unlock = %s, %v; want ACCEPTED", status, err)
+	}
+	if got, err := f.GetTransferBlockedUntil(ctx, "ORDER-BLOCKED-1"); err != nil || got != nil {
+		t.Errorf("GetTransferBlockedUntil after the unlock = %v, %v; want nil", got, err)
+	}
+}
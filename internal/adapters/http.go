@@ -0,0 +1,316 @@
+package adapters
+
+import (
+	"broken-order-service/internal/modal"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTP implementations of the adapters. Each downstream service has its own base URL so they can be
+// pointed at different hosts per environment (or all at cmd/mockdeps locally).
+//
+// Endpoints (relative to the base URL):
+//
+// Side-effecting POSTs carry the attempt's idempotency key in the Idempotency-Key header.
+//
+//	GET  /orders/{orderId}                       -> modal.OrderDetails
+//	POST /orders/{orderId}/notifications         {"template", "vars"}
+//	GET  /transfers/{orderId}                    -> {"status"}
+//	GET  /transfers/{orderId}/attempts           -> []modal.ActionAttemp
+//	GET  /transfers/{orderId}/seats              -> []modal.Seat
+//	GET  /transfers/{orderId}/window             -> {"blockedUntil"}
+//	POST /transfers/{orderId}/retry              {"attempt"} -> {"status"}
+//	POST /transfers/{orderId}/cancel             {"transferKey"}
+//	POST /transfers/{orderId}/replacement        {"reservationId"} -> {"status"}
+//	GET  /suppliers/orders/{orderId}/messages    -> []modal.SupplierMessage
+//	POST /suppliers/orders/{orderId}/ping        {"message"}
+//	POST /suppliers/orders/{orderId}/corrections modal.SeatDiscrepancy -> {"status"}
+//	GET  /payments/{orderId}                     -> {"declineCode"}
+//	GET  /payments/{orderId}/refund-eligibility  -> modal.RefundEligibility
+//	POST /payments/{orderId}/reauthorize         {"attempt"} -> {"declineCode"}
+//	GET  /payments/{orderId}/refund-proposal     -> modal.RefundProposal
+//	POST /payments/{orderId}/refunds             {"amountCents"} -> modal.RefundResult
+//	POST /payments/{orderId}/refunds/{refundId}/void -> modal.RefundResult
+//	GET  /inventory/orders/{orderId}/listings?minPriceCents=&maxPriceCents= -> []modal.SeatListing
+//	POST /inventory/orders/{orderId}/reservations {"listingId"} -> modal.Reservation
+//	POST /inventory/orders/{orderId}/reservations/{reservationId}/release
+//	POST /inventory/orders/{orderId}/reservations/{reservationId}/purchase -> modal.Reservation
+
+var (
+	_ OrderAdapter     = (*HTTPOrderAdapter)(nil)
+	_ TransferAdapter  = (*HTTPTransferAdapter)(nil)
+	_ SupplierAdapter  = (*HTTPSupplierAdapter)(nil)
+	_ PaymentAdapter   = (*HTTPPaymentAdapter)(nil)
+	_ InventoryAdapter = (*HTTPInventoryAdapter)(nil)
+)
+
+// HTTPError is a non-2xx response from a downstream service.
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s %s: status %d: %s", e.Method, e.URL, e.StatusCode, e.Body)
+}
+
+// Retryable reports whether the request may succeed if repeated (server errors and throttling).
+func (e *HTTPError) Retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// httpClient is the shared transport for all HTTP adapters.
+type httpClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func newHTTPClient(baseURL string) httpClient {
+	return httpClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// IdempotencyKeyHeader carries modal.ActionAttemp.IdempotencyKey on side-effecting requests.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// do sends body (if non-nil) as JSON and decodes the response into out (if non-nil).
+func (c httpClient) do(ctx context.Context, method, path string, body, out any) error {
+	return c.doIdempotent(ctx, method, path, "", body, out)
+}
+
+// doIdempotent is do with an idempotency key header (omitted when key is empty).
+func (c httpClient) doIdempotent(ctx context.Context, method, path, key string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	u := c.baseURL + path
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &HTTPError{Method: method, URL: u, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(b))}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+type transferResp struct {
+	Status modal.TransferStatus `json:"status"`
+}
+
+type paymentResp struct {
+	DeclineCode string `json:"declineCode"`
+}
+
+type HTTPOrderAdapter struct{ c httpClient }
+
+func NewHTTPOrderAdapter(baseURL string) *HTTPOrderAdapter {
+	return &HTTPOrderAdapter{c: newHTTPClient(baseURL)}
+}
+
+func (a *HTTPOrderAdapter) GetOrder(ctx context.Context, orderID string) (modal.OrderDetails, error) {
+	var o modal.OrderDetails
+	err := a.c.do(ctx, http.MethodGet, "/orders/"+url.PathEscape(orderID), nil, &o)
+	return o, err
+}
+
+func (a *HTTPOrderAdapter) NotifyBuyer(ctx context.Context, orderID, template string, vars map[string]string, idempotencyKey string) error {
+	body := map[string]any{"template": template, "vars": vars}
+	return a.c.doIdempotent(ctx, http.MethodPost, "/orders/"+url.PathEscape(orderID)+"/notifications", idempotencyKey, body, nil)
+}
+
+type HTTPTransferAdapter struct{ c httpClient }
+
+func NewHTTPTransferAdapter(baseURL string) *HTTPTransferAdapter {
+	return &HTTPTransferAdapter{c: newHTTPClient(baseURL)}
+}
+
+func (a *HTTPTransferAdapter) GetTransferStatus(ctx context.Context, orderID string) (modal.TransferStatus, error) {
+	var resp transferResp
+	err := a.c.do(ctx, http.MethodGet, "/transfers/"+url.PathEscape(orderID), nil, &resp)
+	return resp.Status, err
+}
+
+func (a *HTTPTransferAdapter) GetTransferAttempts(ctx context.Context, orderID string) ([]modal.ActionAttemp, error) {
+	var attempts []modal.ActionAttemp
+	err := a.c.do(ctx, http.MethodGet, "/transfers/"+url.PathEscape(orderID)+"/attempts", nil, &attempts)
+	return attempts, err
+}
+
+func (a *HTTPTransferAdapter) GetDeliveredSeats(ctx context.Context, orderID string) ([]modal.Seat, error) {
+	var seats []modal.Seat
+	err := a.c.do(ctx, http.MethodGet, "/transfers/"+url.PathEscape(orderID)+"/seats", nil, &seats)
+	return seats, err
+}
+
+func (a *HTTPTransferAdapter) GetTransferBlockedUntil(ctx context.Context, orderID string) (*time.Time, error) {
+	var resp struct {
+		BlockedUntil *time.Time `json:"blockedUntil"`
+	}
+	err := a.c.do(ctx, http.MethodGet, "/transfers/"+url.PathEscape(orderID)+"/window", nil, &resp)
+	return resp.BlockedUntil, err
+}
+
+func (a *HTTPTransferAdapter) RetryTransfer(ctx context.Context, orderID string, attempt int, idempotencyKey string) (modal.TransferStatus, error) {
+	var resp transferResp
+	body := map[string]any{"attempt": attempt}
+	err := a.c.doIdempotent(ctx, http.MethodPost, "/transfers/"+url.PathEscape(orderID)+"/retry", idempotencyKey, body, &resp)
+	return resp.Status, err
+}
+
+func (a *HTTPTransferAdapter) CancelTransfer(ctx context.Context, orderID, transferKey, idempotencyKey string) error {
+	body := map[string]any{"transferKey": transferKey}
+	return a.c.doIdempotent(ctx, http.MethodPost, "/transfers/"+url.PathEscape(orderID)+"/cancel", idempotencyKey, body, nil)
+}
+
+func (a *HTTPTransferAdapter) TransferReplacement(ctx context.Context, orderID, reservationID, idempotencyKey string) (modal.TransferStatus, error) {
+	var resp transferResp
+	body := map[string]any{"reservationId": reservationID}
+	err := a.c.doIdempotent(ctx, http.MethodPost, "/transfers/"+url.PathEscape(orderID)+"/replacement", idempotencyKey, body, &resp)
+	return resp.Status, err
+}
+
+type HTTPSupplierAdapter struct{ c httpClient }
+
+func NewHTTPSupplierAdapter(baseURL string) *HTTPSupplierAdapter {
+	return &HTTPSupplierAdapter{c: newHTTPClient(baseURL)}
+}
+
+func (a *HTTPSupplierAdapter) GetCommsHistory(ctx context.Context, orderID string) ([]modal.SupplierMessage, error) {
+	var msgs []modal.SupplierMessage
+	err := a.c.do(ctx, http.MethodGet, "/suppliers/orders/"+url.PathEscape(orderID)+"/messages", nil, &msgs)
+	return msgs, err
+}
+
+func (a *HTTPSupplierAdapter) SendPing(ctx context.Context, orderID, message, idempotencyKey string) error {
+	body := map[string]any{"message": message}
+	return a.c.doIdempotent(ctx, http.MethodPost, "/suppliers/orders/"+url.PathEscape(orderID)+"/ping", idempotencyKey, body, nil)
+}
+
+func (a *HTTPSupplierAdapter) RequestCorrection(ctx context.Context, orderID string, d modal.SeatDiscrepancy, idempotencyKey string) (string, error) {
+	var resp struct {
+		Status string `json:"status"`
+	}
+	err := a.c.doIdempotent(ctx, http.MethodPost, "/suppliers/orders/"+url.PathEscape(orderID)+"/corrections", idempotencyKey, d, &resp)
+	return resp.Status, err
+}
+
+type HTTPPaymentAdapter struct{ c httpClient }
+
+func NewHTTPPaymentAdapter(baseURL string) *HTTPPaymentAdapter {
+	return &HTTPPaymentAdapter{c: newHTTPClient(baseURL)}
+}
+
+func (a *HTTPPaymentAdapter) GetAuthorization(ctx context.Context, orderID string) (modal.PaymentAuthResult, error) {
+	var resp paymentResp
+	if err := a.c.do(ctx, http.MethodGet, "/payments/"+url.PathEscape(orderID), nil, &resp); err != nil {
+		return modal.PaymentAuthResult{}, err
+	}
+	return AuthResult(resp.DeclineCode), nil
+}
+
+func (a *HTTPPaymentAdapter) GetRefundEligibility(ctx context.Context, orderID string) (modal.RefundEligibility, error) {
+	var e modal.RefundEligibility
+	err := a.c.do(ctx, http.MethodGet, "/payments/"+url.PathEscape(orderID)+"/refund-eligibility", nil, &e)
+	return e, err
+}
+
+func (a *HTTPPaymentAdapter) Reauthorize(ctx context.Context, orderID string, attempt int, idempotencyKey string) (modal.PaymentAuthResult, error) {
+	var resp paymentResp
+	body := map[string]any{"attempt": attempt}
+	if err := a.c.doIdempotent(ctx, http.MethodPost, "/payments/"+url.PathEscape(orderID)+"/reauthorize", idempotencyKey, body, &resp); err != nil {
+		return modal.PaymentAuthResult{}, err
+	}
+	return AuthResult(resp.DeclineCode), nil
+}
+
+func (a *HTTPPaymentAdapter) ComputeRefund(ctx context.Context, orderID string) (modal.RefundProposal, error) {
+	var p modal.RefundProposal
+	err := a.c.do(ctx, http.MethodGet, "/payments/"+url.PathEscape(orderID)+"/refund-proposal", nil, &p)
+	return p, err
+}
+
+func (a *HTTPPaymentAdapter) IssueRefund(ctx context.Context, orderID string, amountCents int64, idempotencyKey string) (modal.RefundResult, error) {
+	var res modal.RefundResult
+	body := map[string]any{"amountCents": amountCents}
+	err := a.c.doIdempotent(ctx, http.MethodPost, "/payments/"+url.PathEscape(orderID)+"/refunds", idempotencyKey, body, &res)
+	return res, err
+}
+
+func (a *HTTPPaymentAdapter) VoidRefund(ctx context.Context, orderID, refundID, idempotencyKey string) (modal.RefundResult, error) {
+	var res modal.RefundResult
+	path := "/payments/" + url.PathEscape(orderID) + "/refunds/" + url.PathEscape(refundID) + "/void"
+	err := a.c.doIdempotent(ctx, http.MethodPost, path, idempotencyKey, nil, &res)
+	return res, err
+}
+
+type HTTPInventoryAdapter struct{ c httpClient }
+
+func NewHTTPInventoryAdapter(baseURL string) *HTTPInventoryAdapter {
+	return &HTTPInventoryAdapter{c: newHTTPClient(baseURL)}
+}
+
+func (a *HTTPInventoryAdapter) FindComparableSeats(ctx context.Context, orderID string, band modal.PriceBand) ([]modal.SeatListing, error) {
+	q := url.Values{}
+	q.Set("minPriceCents", strconv.FormatInt(band.MinCents, 10))
+	if band.MaxCents > 0 {
+		q.Set("maxPriceCents", strconv.FormatInt(band.MaxCents, 10))
+	}
+	var listings []modal.SeatListing
+	err := a.c.do(ctx, http.MethodGet, "/inventory/orders/"+url.PathEscape(orderID)+"/listings?"+q.Encode(), nil, &listings)
+	return listings, err
+}
+
+func (a *HTTPInventoryAdapter) ReserveSeats(ctx context.Context, orderID, listingID, idempotencyKey string) (modal.Reservation, error) {
+	var res modal.Reservation
+	body := map[string]any{"listingId": listingID}
+	err := a.c.doIdempotent(ctx, http.MethodPost, "/inventory/orders/"+url.PathEscape(orderID)+"/reservations", idempotencyKey, body, &res)
+	return res, err
+}
+
+func (a *HTTPInventoryAdapter) ReleaseSeats(ctx context.Context, orderID, reservationID, idempotencyKey string) error {
+	path := "/inventory/orders/" + url.PathEscape(orderID) + "/reservations/" + url.PathEscape(reservationID) + "/release"
+	return a.c.doIdempotent(ctx, http.MethodPost, path, idempotencyKey, nil, nil)
+}
+
+func (a *HTTPInventoryAdapter) PurchaseSeats(ctx context.Context, orderID, reservationID, idempotencyKey string) (modal.Reservation, error) {
+	var res modal.Reservation
+	path := "/inventory/orders/" + url.PathEscape(orderID) + "/reservations/" + url.PathEscape(reservationID) + "/purchase"
+	err := a.c.doIdempotent(ctx, http.MethodPost, path, idempotencyKey, nil, &res)
+	return res, err
+}
@@ -0,0 +1,220 @@
+package adapters
+
+import (
+	"broken-order-service/internal/modal"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario scripts how the fake downstream systems behave per order, so production incidents can be
+// reproduced locally (e.g. "ORDER-7: transfer accepted on attempt 3", "ORDER-9: payment 503 twice then 200").
+// It is used by the in-memory Fake and served over HTTP by cmd/mockdeps.
+//
+// Example (YAML):
+//
+//	defaults:
+//	  transfer:
+//	    acceptOnAttempt: 2
+//	orders:
+//	  ORDER-7:
+//	    description: transfer accepted on attempt 3
+//	    transfer:
+//	      acceptOnAttempt: 3
+//	  ORDER-9:
+//	    description: payment 503 twice then 200
+//	    payment:
+//	      declineCodes: [insufficient_funds, ""]
+//	    faults:
+//	      reauthorize: [503, 503]
+type Scenario struct {
+	// Defaults apply to every order not listed in Orders.
+	Defaults OrderScenario            `json:"defaults"`
+	Orders   map[string]OrderScenario `json:"orders"`
+}
+
+// Field names follow the JSON tags in both formats (YAML is converted to JSON before decoding),
+// so order details use the same names as modal.OrderDetails.
+type OrderScenario struct {
+	Description string `json:"description,omitempty"`
+	// Order fields override the generated demo order; zero fields keep the default.
+	Order            modal.OrderDetails      `json:"order"`
+	Transfer         TransferScenario        `json:"transfer"`
+	Payment          PaymentScenario         `json:"payment"`
+	SupplierMessages []modal.SupplierMessage `json:"supplierMessages,omitempty"`
+	// SupplierCorrects makes the supplier deliver the right seats when asked to correct the order.
+	SupplierCorrects bool `json:"supplierCorrects,omitempty"`
+	// Inventory lists replacement seats on sale for the order's event (see InventoryAdapter).
+	Inventory []modal.SeatListing `json:"inventory,omitempty"`
+	// Faults maps an operation (see the Op* constants) to HTTP status codes returned by consecutive calls
+	// before the operation behaves normally, e.g. {"reauthorize": [503, 503]}.
+	Faults map[string][]int `json:"faults,omitempty"`
+}
+
+type TransferScenario struct {
+	Status modal.TransferStatus `json:"status,omitempty"`
+	// AcceptOnAttempt is the first RetryTransfer attempt that succeeds. Nil inherits the default; 0 means never.
+	AcceptOnAttempt *int `json:"acceptOnAttempt,omitempty"`
+	// Attempts are prior transfer attempts already on record before the workflow starts.
+	Attempts []modal.ActionAttemp `json:"attempts,omitempty"`
+	// DeliveredSeats are the seats the buyer received, e.g. the wrong section or only some of the order.
+	// Empty means the order's seats once the transfer is accepted.
+	DeliveredSeats []modal.Seat `json:"deliveredSeats,omitempty"`
+	// UnlockBeforeEvent blocks transfers until this long (a Go duration, e.g. "48h") before the event.
+	UnlockBeforeEvent string `json:"unlockBeforeEvent,omitempty"`
+}
+
+type PaymentScenario struct {
+	// DeclineCodes per authorization ("" = authorized); index 0 is the current authorization
+	// and the last entry repeats. Empty means the payment is authorized.
+	DeclineCodes []string `json:"declineCodes,omitempty"`
+	// Refund overrides the fake's computed refund eligibility.
+	Refund *modal.RefundEligibility `json:"refund,omitempty"`
+}
+
+// LoadScenario reads a scenario from a .yaml/.yml/.json file.
+func LoadScenario(path string) (*Scenario, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".json" {
+		// Decode YAML generically and re-encode as JSON so the JSON tags on modal types apply.
+		var doc any
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return nil, fmt.Errorf("parse scenario %s: %w", path, err)
+		}
+		if b, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("parse scenario %s: %w", path, err)
+		}
+	}
+	var s Scenario
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("parse scenario %s: %w", path, err)
+	}
+	return &s, s.Validate()
+}
+
+// Validate rejects unknown fault operations so typos don't silently disable a fault.
+func (s *Scenario) Validate() error {
+	check := func(name string, o OrderScenario) error {
+		for op := range o.Faults {
+			if !knownOps[op] {
+				return fmt.Errorf("scenario %s: unknown fault operation %q", name, op)
+			}
+		}
+		if d := o.Transfer.UnlockBeforeEvent; d != "" {
+			if _, err := time.ParseDuration(d); err != nil {
+				return fmt.Errorf("scenario %s: transfer.unlockBeforeEvent: %w", name, err)
+			}
+		}
+		return nil
+	}
+	if err := check("defaults", s.Defaults); err != nil {
+		return err
+	}
+	for id, o := range s.Orders {
+		if err := check(id, o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fakeOrder builds the initial fake state for orderID from the scenario.
+func (s *Scenario) fakeOrder(orderID string) (FakeOrder, map[string][]int) {
+	o, ok := s.Orders[orderID]
+	if !ok {
+		o = s.Defaults
+	}
+
+	order := demoOrder(orderID)
+	mergeOrder(&order, s.Defaults.Order)
+	mergeOrder(&order, o.Order)
+
+	fo := FakeOrder{
+		Order:                   order,
+		TransferStatus:          modal.TransferStatus(firstNonEmpty(string(o.Transfer.Status), string(s.Defaults.Transfer.Status), string(modal.TransferNotAccepted))),
+		AcceptTransferOnAttempt: defaultAcceptOnAttempt,
+		TransferAttempts:        append([]modal.ActionAttemp(nil), o.Transfer.Attempts...),
+		DeclineCodes:            o.Payment.DeclineCodes,
+		Refund:                  o.Payment.Refund,
+		DeliveredSeats:          append([]modal.Seat(nil), o.Transfer.DeliveredSeats...),
+		Comms:                   append([]modal.SupplierMessage(nil), o.SupplierMessages...),
+		SupplierCorrects:        o.SupplierCorrects,
+		Inventory:               append([]modal.SeatListing(nil), o.Inventory...),
+	}
+	if d, err := time.ParseDuration(o.Transfer.UnlockBeforeEvent); err == nil {
+		until := order.EventDate.Add(-d)
+		fo.TransferBlockedUntil = &until
+	}
+	switch {
+	case o.Transfer.AcceptOnAttempt != nil:
+		fo.AcceptTransferOnAttempt = *o.Transfer.AcceptOnAttempt
+	case s.Defaults.Transfer.AcceptOnAttempt != nil:
+		fo.AcceptTransferOnAttempt = *s.Defaults.Transfer.AcceptOnAttempt
+	}
+
+	faults := make(map[string][]int, len(o.Faults))
+	for op, codes := range o.Faults {
+		faults[op] = append([]int(nil), codes...)
+	}
+	return fo, faults
+}
+
+// demoOrder generates plausible order details: two seats for an event two weeks out.
+func demoOrder(orderID string) modal.OrderDetails {
+	now := time.Now().UTC().Truncate(time.Minute)
+	return modal.OrderDetails{
+		OrderID:     orderID,
+		BuyerEmail:  defaultBuyerEmail,
+		AmountCents: 24000,
+		Currency:    "USD",
+		PurchasedAt: now.Add(-72 * time.Hour),
+		EventName:   "Demo Night Live",
+		EventDate:   now.Add(14 * 24 * time.Hour),
+		Venue:       "Demo Arena",
+		ListingID:   "LST-" + orderID,
+		SupplierID:  "SUP-DEMO",
+		Seats: []modal.Seat{
+			{Section: "101", Row: "A", Number: "1"},
+			{Section: "101", Row: "A", Number: "2"},
+		},
+	}
+}
+
+// mergeOrder copies the non-zero fields of override into dst (OrderID is never overridden).
+func mergeOrder(dst *modal.OrderDetails, override modal.OrderDetails) {
+	dst.BuyerEmail = firstNonEmpty(override.BuyerEmail, dst.BuyerEmail)
+	dst.Currency = firstNonEmpty(override.Currency, dst.Currency)
+	dst.EventName = firstNonEmpty(override.EventName, dst.EventName)
+	dst.Venue = firstNonEmpty(override.Venue, dst.Venue)
+	dst.ListingID = firstNonEmpty(override.ListingID, dst.ListingID)
+	dst.SupplierID = firstNonEmpty(override.SupplierID, dst.SupplierID)
+	if override.AmountCents != 0 {
+		dst.AmountCents = override.AmountCents
+	}
+	if !override.PurchasedAt.IsZero() {
+		dst.PurchasedAt = override.PurchasedAt
+	}
+	if !override.EventDate.IsZero() {
+		dst.EventDate = override.EventDate
+	}
+	if len(override.Seats) > 0 {
+		dst.Seats = append([]modal.Seat(nil), override.Seats...)
+	}
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+// Package classify decides a broken order's issue type, support tier and confidence from its case file.
+// The workflow calls a Classifier through the ClassifyIssue activity, so implementations may do I/O
+// (e.g. call a model) and their verdict is recorded in history.
+package classify
+
+import (
+	"broken-order-service/internal/modal"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Classifier classifies a case file. A returned error fails the activity (and is retried); a verdict
+// the classifier is unsure about should be returned with a low confidence instead.
+type Classifier interface {
+	Classify(ctx context.Context, cf modal.CaseFile) (modal.Classification, error)
+}
+
+// Rules is the default classifier. It trusts hard evidence (payment and transfer status) over the
+// reporter's hint, and lowers its confidence when the evidence is missing or contradicts the hint.
+type Rules struct{}
+
+func (Rules) Classify(_ context.Context, cf modal.CaseFile) (modal.Classification, error) {
+	c := modal.Classification{Tier: modal.Tier1, Classifier: "rules"}
+	reported := cf.Event.ReportedIssueType
+	available := func(section string) bool { return cf.Sections[section] == modal.SectionAvailable }
+	seats := cf.CompareSeats()
+
+	switch {
+	case cf.PaymentStatus == modal.PaymentHardDeclined:
+		c.IssueType, c.Confidence = modal.IssuePaymentFailed, 0.95
+		c.Tier = modal.Tier2
+		c.Reasons = append(c.Reasons, "payment hard-declined; not recoverable without a human")
+	case cf.PaymentStatus == modal.PaymentSoftDeclined:
+		c.IssueType, c.Confidence = modal.IssuePaymentFailed, 0.95
+		c.Reasons = append(c.Reasons, "payment soft-declined")
+	case cf.TransferStatus != modal.TransferAccepted && supplierCannotFulfill(cf.SupplierComms):
+		c.IssueType, c.Confidence = modal.IssueSupplierCannotFulfill, 0.9
+		c.Reasons = append(c.Reasons, "supplier says it cannot fulfill the order")
+	case cf.TransferStatus != modal.TransferAccepted && reported == modal.IssueSupplierCannotFulfill:
+		// Ops hear about unfulfillable orders from suppliers outside the comms history; trust the reporter
+		// as long as the tickets were not delivered.
+		c.IssueType, c.Confidence = modal.IssueSupplierCannotFulfill, 0.8
+		c.Reasons = append(c.Reasons, "reported as supplier cannot fulfill; tickets not delivered")
+	case len(cf.DeliveredSeats) > 0 && seats.IssueType() == modal.IssueSeatMismatch:
+		c.IssueType, c.Confidence = modal.IssueSeatMismatch, 0.9
+		c.Reasons = append(c.Reasons, fmt.Sprintf("buyer received %d seats that were not sold", len(seats.Unexpected)))
+	case len(cf.DeliveredSeats) > 0 && seats.IssueType() == modal.IssuePartialFulfillment:
+		c.IssueType, c.Confidence = modal.IssuePartialFulfillment, 0.9
+		c.Reasons = append(c.Reasons, fmt.Sprintf("buyer received %d of %d seats sold",
+			len(cf.ExpectedSeats)-len(seats.Missing), len(cf.ExpectedSeats)))
+	case cf.TransferStatus != modal.TransferAccepted && cf.TransferBlockedUntil != nil && cf.TransferBlockedUntil.After(cf.GeneratedAt):
+		// Retrying is pointless until the venue unlocks transfers.
+		c.IssueType, c.Confidence = modal.IssueTransferBlocked, 0.9
+		c.Reasons = append(c.Reasons, "venue blocks transfers until "+cf.TransferBlockedUntil.UTC().Format(time.RFC3339))
+	case available(modal.SectionTransfer) && cf.TransferStatus == modal.TransferNotAccepted:
+		c.IssueType, c.Confidence = modal.IssueTransferFailed, 0.9
+		c.Reasons = append(c.Reasons, "transfer not accepted")
+	case available(modal.SectionTransfer):
+		// Nothing looks broken; the transfer playbook confirms the acceptance and closes the order.
+		c.IssueType, c.Confidence = modal.IssueTransferFailed, 0.75
+		c.Reasons = append(c.Reasons, "transfer already accepted")
+	default:
+		c.IssueType, c.Confidence = modal.IssueTransferFailed, 0.4
+		if reported.Known() {
+			c.IssueType = reported
+		}
+		c.Reasons = append(c.Reasons, "transfer and payment status unavailable")
+	}
+
+	if reported.Known() && reported != c.IssueType {
+		c.Confidence -= 0.2
+		c.Reasons = append(c.Reasons, fmt.Sprintf("reported as %s", reported))
+	}
+	if cf.Event.Priority == modal.PriorityHigh {
+		c.Tier = modal.Tier2
+		c.Reasons = append(c.Reasons, "high priority event")
+	}
+	return c, nil
+}
+
+// cannotFulfillPhrases are what suppliers write when they cannot deliver the seats sold.
+var cannotFulfillPhrases = []string{"cannot fulfil", "can't fulfil", "unable to fulfil", "no longer available"}
+
+// supplierCannotFulfill reports whether an inbound supplier message says the order cannot be fulfilled.
+func supplierCannotFulfill(comms []modal.SupplierMessage) bool {
+	for _, m := range comms {
+		if m.Direction != "INBOUND" {
+			continue
+		}
+		body := strings.ToLower(m.Body)
+		for _, p := range cannotFulfillPhrases {
+			if strings.Contains(body, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package classify
+
+import (
+	"broken-order-service/internal/modal"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Model is the hook for a language model: it completes a prompt. Any LLM client can be adapted to it.
+type Model interface {
+	Complete(ctx context.Context, prompt string) (string, error)
+}
+
+// LLM classifies with a Model. The model only suggests: its answer is parsed and validated, and anything
+// unusable (an error, malformed JSON, an unknown issue type) falls back to Fallback, Rules by default.
+type LLM struct {
+	Model    Model
+	Fallback Classifier
+}
+
+// llmAnswer is the JSON the prompt asks the model to return.
+type llmAnswer struct {
+	IssueType  modal.IssueType `json:"issueType"`
+	Tier       string          `json:"tier"`
+	Confidence float64         `json:"confidence"`
+	Reasons    []string        `json:"reasons"`
+}
+
+func (l *LLM) Classify(ctx context.Context, cf modal.CaseFile) (modal.Classification, error) {
+	prompt, err := Prompt(cf)
+	if err != nil {
+		return modal.Classification{}, err
+	}
+	out, err := l.Model.Complete(ctx, prompt)
+	if err != nil {
+		return l.fallback(ctx, cf, fmt.Sprintf("model unavailable: %v", err))
+	}
+	a, err := parseAnswer(out)
+	if err != nil {
+		return l.fallback(ctx, cf, fmt.Sprintf("unusable model answer: %v", err))
+	}
+	return modal.Classification{
+		IssueType:  a.IssueType,
+		Tier:       a.Tier,
+		Confidence: a.Confidence,
+		Reasons:    a.Reasons,
+		Classifier: "llm",
+	}, nil
+}
+
+func (l *LLM) fallback(ctx context.Context, cf modal.CaseFile, reason string) (modal.Classification, error) {
+	fb := l.Fallback
+	if fb == nil {
+		fb = Rules{}
+	}
+	c, err := fb.Classify(ctx, cf)
+	if err != nil {
+		return modal.Classification{}, err
+	}
+	c.Reasons = append(c.Reasons, reason)
+	return c, nil
+}
+
+// Prompt renders the case file signals a model needs. Evidence sections are included as JSON; buyer
+// contact details are left out.
+func Prompt(cf modal.CaseFile) (string, error) {
+	signals := map[string]any{
+		"reportedIssueType":    cf.Event.ReportedIssueType,
+		"priority":             cf.Event.Priority,
+		"transferStatus":       cf.TransferStatus,
+		"paymentStatus":        cf.PaymentStatus,
+		"transferAttempts":     len(cf.TransferAttempts),
+		"supplierComms":        cf.SupplierComms,
+		"expectedSeats":        cf.ExpectedSeats,
+		"deliveredSeats":       cf.DeliveredSeats,
+		"transferBlockedUntil": cf.TransferBlockedUntil,
+		"sections":             cf.Sections,
+	}
+	b, err := json.MarshalIndent(signals, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString("Classify this broken ticket order for the ops team.\n")
+	fmt.Fprintf(&sb, "issueType must be one of: %v.\n", modal.IssueTypes)
+	fmt.Fprintf(&sb, "tier must be %s (routine) or %s (risky or urgent).\n", modal.Tier1, modal.Tier2)
+	sb.WriteString("confidence is between 0 and 1. Answer with JSON only: ")
+	sb.WriteString(`{"issueType":"...","tier":"...","confidence":0.0,"reasons":["..."]}` + "\n\n")
+	sb.Write(b)
+	return sb.String(), nil
+}
+
+func parseAnswer(out string) (llmAnswer, error) {
+	// Models like to wrap JSON in prose or code fences; take the outermost object.
+	start, end := strings.IndexByte(out, '{'), strings.LastIndexByte(out, '}')
+	if start < 0 || end < start {
+		return llmAnswer{}, fmt.Errorf("no JSON object")
+	}
+	var a llmAnswer
+	if err := json.Unmarshal([]byte(out[start:end+1]), &a); err != nil {
+		return llmAnswer{}, err
+	}
+	if !a.IssueType.Known() {
+		return llmAnswer{}, fmt.Errorf("unknown issue type %q", a.IssueType)
+	}
+	if a.Tier != modal.Tier1 && a.Tier != modal.Tier2 {
+		return llmAnswer{}, fmt.Errorf("unknown tier %q", a.Tier)
+	}
+	if a.Confidence < 0 || a.Confidence > 1 {
+		return llmAnswer{}, fmt.Errorf("confidence %v out of range", a.Confidence)
+	}
+	return a, nil
+}
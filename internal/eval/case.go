@@ -0,0 +1,146 @@
+// Package eval replays golden cases through the classifier and the ResolveBrokenOrder workflow (in
+// Temporal's test environment, against the fake adapters) and scores the outcome against a rubric.
+package eval
+
+import (
+	"broken-order-service/internal/adapters"
+	"broken-order-service/internal/modal"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Case is one golden case: an event, how the downstream systems behave for its order, the human
+// decisions made along the way, and the expected outcome.
+//
+// Example (YAML):
+//
+//	name: transfer review approved
+//	event: {eventId: golden-fail-approve, orderId: ORDER-FAIL-1}
+//	decisions:
+//	  - taskType: RETRY_TRANSFER
+//	    approved: true
+//	expect:
+//	  issueType: TRANSFER_FAILED
+//	  result: ESCALATED_APPROVED
+//	  tasks: [RETRY_TRANSFER]
+//	  actions: [RETRY_TRANSFER, RETRY_TRANSFER, RETRY_TRANSFER, PING_SUPPLIER]
+type Case struct {
+	Name  string                 `json:"name"`
+	Event modal.BrokenOrderEvent `json:"event"`
+	// Scenario overrides the base scenario's entry for the event's order. Nil keeps the base scenario.
+	Scenario  *adapters.OrderScenario `json:"scenario,omitempty"`
+	Decisions []Decision              `json:"decisions,omitempty"`
+	Expect    Expect                  `json:"expect"`
+
+	// File is the file the case was loaded from.
+	File string `json:"-"`
+}
+
+// Decision is a human decision sent to the oldest open task of TaskType, After the workflow started.
+type Decision struct {
+	TaskType string `json:"taskType"`
+	// After is a Go duration string; default 1m. Time in the test environment is simulated.
+	After     string          `json:"after,omitempty"`
+	Approved  bool            `json:"approved"`
+	Decider   string          `json:"decider,omitempty"`
+	Notes     string          `json:"notes,omitempty"`
+	IssueType modal.IssueType `json:"issueType,omitempty"`
+}
+
+// Expect holds the expected outcome. Empty fields are not scored; an empty list (tasks: []) is
+// scored and means "none".
+type Expect struct {
+	IssueType modal.IssueType `json:"issueType,omitempty"`
+	Tier      string          `json:"tier,omitempty"`
+	Result    modal.Outcome   `json:"result,omitempty"`
+	// Tasks are the human task types created, in order.
+	Tasks []string `json:"tasks"`
+	// Actions are the side effects in the attempt ledger (modal.ActionAttemp.ActionType), in order.
+	Actions []string `json:"actions"`
+}
+
+func (d Decision) delay() (time.Duration, error) {
+	if d.After == "" {
+		return time.Minute, nil
+	}
+	return time.ParseDuration(d.After)
+}
+
+// LoadCases reads every .yaml/.yml/.json case in dir, sorted by file name.
+func LoadCases(dir string) ([]Case, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".yaml", ".yml", ".json":
+			if !e.IsDir() {
+				names = append(names, e.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+
+	cases := make([]Case, 0, len(names))
+	for _, name := range names {
+		c, err := loadCase(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+func loadCase(path string) (Case, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Case{}, err
+	}
+	if strings.ToLower(filepath.Ext(path)) != ".json" {
+		// Same approach as adapters.LoadScenario: YAML is re-encoded as JSON so the JSON tags apply.
+		var doc any
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return Case{}, fmt.Errorf("parse case %s: %w", path, err)
+		}
+		if b, err = json.Marshal(doc); err != nil {
+			return Case{}, fmt.Errorf("parse case %s: %w", path, err)
+		}
+	}
+	var c Case
+	if err := json.Unmarshal(b, &c); err != nil {
+		return Case{}, fmt.Errorf("parse case %s: %w", path, err)
+	}
+	c.File = path
+	if c.Name == "" {
+		c.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return c, c.validate()
+}
+
+func (c Case) validate() error {
+	if err := c.Event.Validate(); err != nil {
+		return fmt.Errorf("case %s: event: %w", c.Name, err)
+	}
+	if c.Expect.Result != "" && !c.Expect.Result.Known() {
+		return fmt.Errorf("case %s: expect.result %q is not an outcome", c.Name, c.Expect.Result)
+	}
+	for i, d := range c.Decisions {
+		if d.TaskType == "" {
+			return fmt.Errorf("case %s: decision %d: taskType is required", c.Name, i+1)
+		}
+		if _, err := d.delay(); err != nil {
+			return fmt.Errorf("case %s: decision %d: after: %w", c.Name, i+1, err)
+		}
+	}
+	return nil
+}
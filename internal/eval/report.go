@@ -0,0 +1,136 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// rule is one line of the rubric. check returns whether the rule applies to the case and, if so, the
+// expected and actual values; the rule passes when they are equal.
+type rule struct {
+	name   string
+	weight int
+	check  func(c Case, o Outcome) (scored bool, want, got string)
+}
+
+// rubric weights the final result highest: a wrong result is a wrong resolution, whereas a different
+// action sequence that reaches the right result is a smaller regression.
+var rubric = []rule{
+	{"issueType", 2, func(c Case, o Outcome) (bool, string, string) {
+		return c.Expect.IssueType != "", string(c.Expect.IssueType), string(o.IssueType)
+	}},
+	{"tier", 1, func(c Case, o Outcome) (bool, string, string) {
+		return c.Expect.Tier != "", c.Expect.Tier, o.Classification.Tier
+	}},
+	{"result", 3, func(c Case, o Outcome) (bool, string, string) {
+		got := string(o.Resolution.Outcome)
+		if o.Err != nil {
+			got = "error: " + o.Err.Error()
+		}
+		return c.Expect.Result != "", string(c.Expect.Result), got
+	}},
+	{"tasks", 2, func(c Case, o Outcome) (bool, string, string) {
+		return c.Expect.Tasks != nil, list(c.Expect.Tasks), list(o.Tasks)
+	}},
+	{"actions", 2, func(c Case, o Outcome) (bool, string, string) {
+		return c.Expect.Actions != nil, list(c.Expect.Actions), list(o.Actions)
+	}},
+	{"decisions", 1, func(c Case, o Outcome) (bool, string, string) {
+		got := "all delivered"
+		if len(o.Undelivered) > 0 {
+			got = "undelivered: " + strings.Join(o.Undelivered, "; ")
+		}
+		return len(c.Decisions) > 0, "all delivered", got
+	}},
+}
+
+func list(s []string) string {
+	return "[" + strings.Join(s, ", ") + "]"
+}
+
+// RuleResult is the score of one rubric rule for one case.
+type RuleResult struct {
+	Rule   string
+	Weight int
+	Pass   bool
+	Want   string
+	Got    string
+}
+
+// CaseResult is a scored case.
+type CaseResult struct {
+	Case    Case
+	Outcome Outcome
+	Rules   []RuleResult
+	Score   int
+	Max     int
+}
+
+func (r CaseResult) Passed() bool {
+	return r.Score == r.Max
+}
+
+// Score applies the rubric to a case's outcome.
+func Score(c Case, o Outcome) CaseResult {
+	res := CaseResult{Case: c, Outcome: o}
+	for _, rl := range rubric {
+		scored, want, got := rl.check(c, o)
+		if !scored {
+			continue
+		}
+		rr := RuleResult{Rule: rl.name, Weight: rl.weight, Pass: want == got, Want: want, Got: got}
+		res.Rules = append(res.Rules, rr)
+		res.Max += rl.weight
+		if rr.Pass {
+			res.Score += rl.weight
+		}
+	}
+	return res
+}
+
+// Report is the scored result of a set of cases.
+type Report struct {
+	Cases []CaseResult
+}
+
+func (r Report) Passed() bool {
+	return !slices.ContainsFunc(r.Cases, func(c CaseResult) bool { return !c.Passed() })
+}
+
+// Write prints one line per case and a diff line for every failed rule. verbose also lists passing rules.
+func (r Report) Write(w io.Writer, verbose bool) {
+	score, max, passed := 0, 0, 0
+	for _, c := range r.Cases {
+		status := "FAIL"
+		if c.Passed() {
+			status = "PASS"
+			passed++
+		}
+		score += c.Score
+		max += c.Max
+		fmt.Fprintf(w, "%s  %-45s %2d/%-2d  %s\n", status, c.Case.Name, c.Score, c.Max, c.Case.File)
+		for _, rr := range c.Rules {
+			switch {
+			case !rr.Pass:
+				fmt.Fprintf(w, "      - %s (%d): want %s\n", rr.Rule, rr.Weight, rr.Want)
+				fmt.Fprintf(w, "      + %s (%d): got  %s\n", rr.Rule, rr.Weight, rr.Got)
+			case verbose:
+				fmt.Fprintf(w, "        %s (%d): %s\n", rr.Rule, rr.Weight, rr.Got)
+			}
+		}
+		if verbose {
+			cl := c.Outcome.Classification
+			fmt.Fprintf(w, "        classified %s/%s at %.2f by %s: %s\n", cl.IssueType, cl.Tier, cl.Confidence, cl.Classifier, strings.Join(cl.Reasons, "; "))
+			if res := c.Outcome.Resolution; res.Outcome != "" {
+				fmt.Fprintf(w, "        resolved %s by %s: %s\n", res.Outcome, res.DecidedBy, res.Reason)
+			}
+		}
+	}
+	pct := 100.0
+	if max > 0 {
+		pct = 100 * float64(score) / float64(max)
+	}
+	fmt.Fprintf(w, "\n%d/%d cases passed, score %d/%d (%.1f%%)\n", passed, len(r.Cases), score, max, pct)
+}
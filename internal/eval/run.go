@@ -0,0 +1,151 @@
+package eval
+
+import (
+	"broken-order-service/internal/activities"
+	"broken-order-service/internal/adapters"
+	"broken-order-service/internal/classify"
+	"broken-order-service/internal/modal"
+	"broken-order-service/internal/playbook"
+	"broken-order-service/internal/workflows"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// Outcome is what one run of a case produced.
+type Outcome struct {
+	Classification modal.Classification
+	// IssueType is the case file's final issue type (after triage, if there was one).
+	IssueType  modal.IssueType
+	Resolution modal.Resolution
+	Err        error
+	// Tasks are the human task types created, in order.
+	Tasks []string
+	// Actions are the ledger's action types, in order.
+	Actions []string
+	// Undelivered lists decisions that found no open task of their type.
+	Undelivered []string
+}
+
+// Runner replays cases. Each case gets a fresh fake and a fresh test environment, so cases are independent.
+type Runner struct {
+	// Scenario is the base scenario; a case's own scenario replaces its order's entry. Nil means defaults only.
+	Scenario *adapters.Scenario
+	// Playbooks resolves issue types to playbooks. Nil means built-in defaults only.
+	Playbooks *playbook.Store
+	// Classifier is the classifier under evaluation. Nil means classify.Rules.
+	Classifier classify.Classifier
+	// Logger receives the workflow and SDK logs. Nil discards them.
+	Logger log.Logger
+}
+
+// Run executes c's workflow to completion. Timers and SLAs run on simulated time.
+func (r *Runner) Run(c Case) Outcome {
+	var out Outcome
+
+	fake := adapters.NewFakeFromScenario(r.scenario(c))
+	a := activities.NewFakeActivities(fake, r.Playbooks)
+	a.Classifier = r.Classifier
+
+	var suite testsuite.WorkflowTestSuite
+	suite.SetLogger(r.logger())
+	env := suite.NewTestWorkflowEnvironment()
+	// The fake follows simulated time, so a transfer blocked until shortly before the event unlocks once the
+	// workflow has slept until then.
+	fake.SetClock(env.Now)
+	env.RegisterWorkflow(workflows.ResolveBrokenOrder)
+	env.RegisterWorkflow(workflows.SourceReplacement)
+	env.RegisterWorkflow(workflows.NotifyBuyer)
+	env.RegisterActivity(a)
+	env.SetStartWorkflowOptions(client.StartWorkflowOptions{ID: c.Event.WorkflowID()})
+
+	for _, d := range c.Decisions {
+		delay, _ := d.delay() // validated when the case was loaded
+		env.RegisterDelayedCallback(func() {
+			if err := decide(env, d); err != nil {
+				out.Undelivered = append(out.Undelivered, fmt.Sprintf("%s after %s: %v", d.TaskType, delay, err))
+			}
+		}, delay)
+	}
+
+	env.ExecuteWorkflow(workflows.ResolveBrokenOrder, c.Event)
+	if err := env.GetWorkflowError(); err != nil {
+		out.Err = err
+	} else if err := env.GetWorkflowResult(&out.Resolution); err != nil {
+		out.Err = err
+	}
+
+	var cf modal.CaseFile
+	if v, err := env.QueryWorkflow("casefile"); err == nil && v.Get(&cf) == nil {
+		out.Classification = cf.Classification
+		out.IssueType = cf.IssueType
+	}
+	var tasks []modal.HumanTask
+	if v, err := env.QueryWorkflow("tasks"); err == nil && v.Get(&tasks) == nil {
+		for _, t := range tasks {
+			out.Tasks = append(out.Tasks, t.Type)
+		}
+	}
+	var attempts []modal.ActionAttemp
+	if v, err := env.QueryWorkflow("attempts"); err == nil && v.Get(&attempts) == nil {
+		for _, at := range attempts {
+			out.Actions = append(out.Actions, at.ActionType)
+		}
+	}
+	return out
+}
+
+// decide signals d to the oldest open task of its type.
+func decide(env *testsuite.TestWorkflowEnvironment, d Decision) error {
+	v, err := env.QueryWorkflow("tasks")
+	if err != nil {
+		return err
+	}
+	var tasks []modal.HumanTask
+	if err := v.Get(&tasks); err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		if t.Type != d.TaskType || !t.Status.Active() {
+			continue
+		}
+		decider := d.Decider
+		if decider == "" {
+			decider = "eval"
+		}
+		env.SignalWorkflow(workflows.TaskDecisionSignal, modal.TaskDecision{
+			TaskID:    t.ID,
+			Approved:  d.Approved,
+			Notes:     d.Notes,
+			Decider:   decider,
+			IssueType: d.IssueType,
+		})
+		return nil
+	}
+	return fmt.Errorf("no open task")
+}
+
+func (r *Runner) scenario(c Case) *adapters.Scenario {
+	sc := &adapters.Scenario{Orders: make(map[string]adapters.OrderScenario)}
+	if r.Scenario != nil {
+		sc.Defaults = r.Scenario.Defaults
+		for id, o := range r.Scenario.Orders {
+			sc.Orders[id] = o
+		}
+	}
+	if c.Scenario != nil {
+		sc.Orders[c.Event.OrderID] = *c.Scenario
+	}
+	return sc
+}
+
+func (r *Runner) logger() log.Logger {
+	if r.Logger != nil {
+		return r.Logger
+	}
+	return log.NewStructuredLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
@@ -0,0 +1,179 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDuplicate is returned by a StartFunc when the event's workflow already exists.
+var ErrDuplicate = errors.New("workflow already started")
+
+// StartFunc starts the workflow for an event.
+type StartFunc func(ctx context.Context, e Event) error
+
+const (
+	defaultConcurrency  = 4
+	defaultMaxAttempts  = 5
+	defaultRetryBackoff = 500 * time.Millisecond
+	defaultDedupWindow  = 100_000
+)
+
+// Consumer pulls events from Source and starts a workflow for each through Start, with at most
+// Concurrency starts in flight. While every slot is busy it stops reading from Source (backpressure).
+// Events are deduplicated by event ID within the last DedupWindow IDs; Start failures are retried with
+// exponential backoff, and an event that still fails is forgotten so a redelivery can try again.
+type Consumer struct {
+	Source      EventSource
+	Start       StartFunc
+	Concurrency int
+	MaxAttempts int
+	DedupWindow int
+
+	seen  *seenSet
+	stats Stats
+}
+
+// Stats counts what the consumer did with each event.
+type Stats struct {
+	Started    atomic.Int64
+	Duplicates atomic.Int64
+	Failed     atomic.Int64
+}
+
+func NewConsumer(src EventSource, start StartFunc, concurrency int) *Consumer {
+	return &Consumer{Source: src, Start: start, Concurrency: concurrency}
+}
+
+// Stats returns the consumer's counters.
+func (c *Consumer) Stats() *Stats {
+	return &c.stats
+}
+
+// Run consumes until the source is exhausted (io.EOF) or ctx is cancelled, then waits for in-flight
+// starts to finish. It returns nil on exhaustion or cancellation and the source's error otherwise.
+func (c *Consumer) Run(ctx context.Context) error {
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	window := c.DedupWindow
+	if window <= 0 {
+		window = defaultDedupWindow
+	}
+	c.seen = newSeenSet(window)
+
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		e, err := c.Source.Next(ctx)
+		if err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if !c.seen.add(e.EventID) {
+			c.stats.Duplicates.Add(1)
+			log.Printf("[ingest] duplicate event=%s order=%s dropped", e.EventID, e.OrderID)
+			continue
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			c.start(ctx, e)
+		}()
+	}
+}
+
+func (c *Consumer) start(ctx context.Context, e Event) {
+	attempts := c.MaxAttempts
+	if attempts <= 0 {
+		attempts = defaultMaxAttempts
+	}
+	backoff := defaultRetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := c.Start(ctx, e)
+		switch {
+		case err == nil:
+			c.stats.Started.Add(1)
+			log.Printf("[ingest] started event=%s order=%s", e.EventID, e.OrderID)
+			return
+		case errors.Is(err, ErrDuplicate):
+			c.stats.Duplicates.Add(1)
+			log.Printf("[ingest] event=%s order=%s already has a workflow", e.EventID, e.OrderID)
+			return
+		case attempt >= attempts || ctx.Err() != nil:
+			c.stats.Failed.Add(1)
+			c.seen.remove(e.EventID)
+			log.Printf("[ingest] failed to start event=%s order=%s after %d attempts: %v", e.EventID, e.OrderID, attempt, err)
+			return
+		}
+
+		log.Printf("[ingest] start event=%s attempt=%d failed, retrying in %s: %v", e.EventID, attempt, backoff, err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// seenSet remembers the most recent event IDs, evicting the oldest beyond its capacity.
+type seenSet struct {
+	mu    sync.Mutex
+	max   int
+	ids   map[string]bool
+	order []string
+}
+
+func newSeenSet(max int) *seenSet {
+	return &seenSet{max: max, ids: make(map[string]bool)}
+}
+
+// add records id and reports whether it was new.
+func (s *seenSet) add(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ids[id] {
+		return false
+	}
+	s.ids[id] = true
+	s.order = append(s.order, id)
+	if len(s.order) > s.max {
+		delete(s.ids, s.order[0])
+		s.order = s.order[1:]
+	}
+	return true
+}
+
+// remove forgets id, so a redelivery of its event counts as new. The ID leaves the eviction order too: left
+// there, it would evict the redelivered ID once it reached the front.
+func (s *seenSet) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ids[id] {
+		return
+	}
+	delete(s.ids, id)
+	for i, o := range s.order {
+		if o == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// run feeds events to a consumer through a ChannelSource and waits for it to finish.
+func run(t *testing.T, c *Consumer, events ...Event) {
+	t.Helper()
+	ch := make(chan Event, len(events))
+	for _, e := range events {
+		ch <- e
+	}
+	close(ch)
+	c.Source = NewChannelSource(ch)
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConsumerDropsDuplicateEvents(t *testing.T) {
+	var mu sync.Mutex
+	started := map[string]int{}
+	c := &Consumer{Start: func(ctx context.Context, e Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		started[e.EventID]++
+		if e.EventID == "evt-3" {
+			return ErrDuplicate // the workflow was started by an earlier run
+		}
+		return nil
+	}}
+	run(t, c, Event{EventID: "evt-1"}, Event{EventID: "evt-2"}, Event{EventID: "evt-1"}, Event{EventID: "evt-3"})
+
+	if started["evt-1"] != 1 || started["evt-2"] != 1 {
+		t.Errorf("starts = %v, want one per event ID", started)
+	}
+	if got := c.Stats().Started.Load(); got != 2 {
+		t.Errorf("Started = %d, want 2", got)
+	}
+	if got := c.Stats().Duplicates.Load(); got != 2 {
+		t.Errorf("Duplicates = %d, want 2 (a redelivery and an existing workflow)", got)
+	}
+}
+
+func TestSeenSetEvictsOldest(t *testing.T) {
+	s := newSeenSet(2)
+	for _, id := range []string{"a", "b", "c"} {
+		if !s.add(id) {
+			t.Fatalf("add(%s) reported a duplicate", id)
+		}
+	}
+	if s.add("c") {
+		t.Error("c is within the window but was not a duplicate")
+	}
+	if !s.add("a") {
+		t.Error("a was evicted but still counted as a duplicate")
+	}
+}
+
+func TestSeenSetRemoveForgetsID(t *testing.T) {
+	s := newSeenSet(2)
+	s.add("a")
+	s.add("b")
+	s.remove("a")
+	if !s.add("a") {
+		t.Fatal("a was removed but still counted as a duplicate")
+	}
+	if s.add("a") {
+		t.Error("a was re-added but is no longer deduplicated")
+	}
+	if s.add("b") {
+		t.Error("b is within the window but was not a duplicate")
+	}
+}
+
+func TestConsumerRetriesFailedStart(t *testing.T) {
+	var calls atomic.Int64
+	c := &Consumer{MaxAttempts: 2, Start: func(ctx context.Context, e Event) error {
+		if calls.Add(1) == 1 {
+			return errors.New("frontend unavailable")
+		}
+		return nil
+	}}
+	run(t, c, Event{EventID: "evt-1"})
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("Start called %d times, want 2", got)
+	}
+	if got := c.Stats().Started.Load(); got != 1 {
+		t.Errorf("Started = %d, want 1", got)
+	}
+	if got := c.Stats().Failed.Load(); got != 0 {
+		t.Errorf("Failed = %d, want 0", got)
+	}
+}
+
+// An event whose start still fails after every attempt is forgotten, so a redelivery gets another try.
+func TestConsumerRetriesRedeliveryAfterFailure(t *testing.T) {
+	var calls atomic.Int64
+	ch := make(chan Event)
+	c := &Consumer{
+		Source:      NewChannelSource(ch),
+		MaxAttempts: 1,
+		Start: func(ctx context.Context, e Event) error {
+			if calls.Add(1) == 1 {
+				return errors.New("frontend unavailable")
+			}
+			return nil
+		},
+	}
+	done := make(chan error)
+	go func() { done <- c.Run(context.Background()) }()
+
+	ch <- Event{EventID: "evt-1"}
+	waitFor(t, "the first start to fail", func() bool { return c.Stats().Failed.Load() == 1 })
+	ch <- Event{EventID: "evt-1"}
+	close(ch)
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if got := c.Stats().Started.Load(); got != 1 {
+		t.Errorf("Started = %d, want the redelivery to start", got)
+	}
+	if got := c.Stats().Duplicates.Load(); got != 0 {
+		t.Errorf("Duplicates = %d, want 0", got)
+	}
+}
+
+// countingSource counts how many events the consumer has pulled.
+type countingSource struct {
+	EventSource
+	reads atomic.Int64
+}
+
+func (s *countingSource) Next(ctx context.Context) (Event, error) {
+	e, err := s.EventSource.Next(ctx)
+	if err == nil {
+		s.reads.Add(1)
+	}
+	return e, err
+}
+
+func TestConsumerStopsReadingWhileSlotsAreBusy(t *testing.T) {
+	const events = 5
+	ch := make(chan Event, events)
+	for i := range events {
+		ch <- Event{EventID: string(rune('a' + i))}
+	}
+	close(ch)
+	src := &countingSource{EventSource: NewChannelSource(ch)}
+
+	var inFlight, maxInFlight atomic.Int64
+	release := make(chan struct{})
+	c := &Consumer{Source: src, Concurrency: 2, Start: func(ctx context.Context, e Event) error {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		<-release
+		return nil
+	}}
+	done := make(chan error)
+	go func() { done <- c.Run(context.Background()) }()
+
+	waitFor(t, "both slots to fill", func() bool { return inFlight.Load() == 2 })
+	time.Sleep(20 * time.Millisecond)
+	// Two events are starting and a third is waiting for a slot; the rest stay in the source.
+	if got := src.reads.Load(); got != 3 {
+		t.Errorf("read %d events while both slots were busy, want 3", got)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if got := maxInFlight.Load(); got != 2 {
+		t.Errorf("at most %d starts were in flight, want 2", got)
+	}
+	if got := c.Stats().Started.Load(); got != events {
+		t.Errorf("Started = %d, want %d", got, events)
+	}
+}
@@ -0,0 +1,148 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const defaultPollInterval = time.Second
+
+// FileSource reads JSONL events (one JSON object per line) from a file, or from every *.jsonl file in
+// a directory in name order. With Follow set it keeps tailing: new lines appended to known files and
+// new files dropped into the directory are picked up on the next poll, which makes a directory a
+// simple local broker. Malformed lines are logged and skipped.
+//
+// Offsets are kept in memory only; after a restart files are re-read from the start and the
+// consumer's duplicate handling drops events whose workflow already exists.
+type FileSource struct {
+	Path         string
+	Follow       bool
+	PollInterval time.Duration
+
+	offsets map[string]int64
+	pending []Event
+}
+
+func NewFileSource(path string, follow bool) *FileSource {
+	return &FileSource{Path: path, Follow: follow, PollInterval: defaultPollInterval}
+}
+
+func (s *FileSource) Next(ctx context.Context) (Event, error) {
+	for {
+		if len(s.pending) > 0 {
+			e := s.pending[0]
+			s.pending = s.pending[1:]
+			return e, nil
+		}
+		if err := s.scan(); err != nil {
+			return Event{}, err
+		}
+		if len(s.pending) > 0 {
+			continue
+		}
+		if !s.Follow {
+			return Event{}, io.EOF
+		}
+
+		poll := s.PollInterval
+		if poll <= 0 {
+			poll = defaultPollInterval
+		}
+		select {
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		case <-time.After(poll):
+		}
+	}
+}
+
+// scan reads the complete lines added to each file since the last scan into s.pending.
+func (s *FileSource) scan() error {
+	if s.offsets == nil {
+		s.offsets = make(map[string]int64)
+	}
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := s.scanFile(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FileSource) files() ([]string, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{s.Path}, nil
+	}
+	files, err := filepath.Glob(filepath.Join(s.Path, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (s *FileSource) scanFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	offset := s.offsets[path]
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	// When following, only consume complete lines; a line still being written is picked up on a later scan.
+	// Without Follow there is no later scan, so a last line without a trailing newline is read as it is.
+	end := len(b)
+	if s.Follow {
+		end = bytes.LastIndexByte(b, '\n') + 1
+	}
+	if end == 0 {
+		return nil
+	}
+	for i, line := range bytes.Split(b[:end], []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(line, &e); err != nil {
+			log.Printf("[ingest] skipping malformed line in %s: %v", path, err)
+			continue
+		}
+		if err := e.Validate(); err != nil {
+			log.Printf("[ingest] skipping event %d after offset %d in %s: %v", i+1, offset, path, err)
+			continue
+		}
+		if e.Source == "" {
+			e.Source = "file"
+		}
+		if e.ReceivedAt.IsZero() {
+			e.ReceivedAt = time.Now().UTC()
+		}
+		s.pending = append(s.pending, e)
+	}
+	s.offsets[path] = offset + int64(end)
+	return nil
+}
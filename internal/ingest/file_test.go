@@ -0,0 +1,79 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	line1 = `{"eventId": "evt-1", "orderId": "ORDER-1"}`
+	line2 = `{"eventId": "evt-2", "orderId": "ORDER-2"}`
+)
+
+// readAll drains src until it returns an error, returning the event IDs read and that error.
+func readAll(ctx context.Context, src EventSource) ([]string, error) {
+	var ids []string
+	for {
+		e, err := src.Next(ctx)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, e.EventID)
+	}
+}
+
+func writeEvents(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Without Follow the file is read once, so a last line without a trailing newline is still an event.
+func TestFileSourceReadsLastLineWithoutNewline(t *testing.T) {
+	src := NewFileSource(writeEvents(t, line1+"\n"+line2), false)
+	ids, err := readAll(context.Background(), src)
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("Next: %v, want io.EOF", err)
+	}
+	if len(ids) != 2 || ids[0] != "evt-1" || ids[1] != "evt-2" {
+		t.Errorf("read %v, want [evt-1 evt-2]", ids)
+	}
+}
+
+// While following, a last line without a newline may still be being written, so it waits for the newline.
+func TestFileSourceFollowHoldsBackPartialLine(t *testing.T) {
+	path := writeEvents(t, line1+"\n"+line2)
+	src := NewFileSource(path, true)
+	src.PollInterval = time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	ids, err := readAll(ctx, src)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Next: %v, want the context deadline", err)
+	}
+	if len(ids) != 1 || ids[0] != "evt-1" {
+		t.Fatalf("read %v before the newline, want [evt-1]", ids)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	e, err := src.Next(context.Background())
+	if err != nil || e.EventID != "evt-2" {
+		t.Errorf("Next after the newline = %s, %v; want evt-2", e.EventID, err)
+	}
+}
@@ -0,0 +1,41 @@
+// Package ingest consumes broken-order events from a source and starts a ResolveBrokenOrder
+// workflow for each one.
+package ingest
+
+import (
+	"broken-order-service/internal/modal"
+	"context"
+	"io"
+)
+
+// Event is one broken-order report; events with an EventID already seen are dropped.
+type Event = modal.BrokenOrderEvent
+
+// EventSource delivers events one at a time. Next blocks until an event is available and returns
+// io.EOF once a finite source is exhausted. Pulling (rather than pushing) gives backpressure: a source
+// is only read as fast as the consumer can start workflows.
+type EventSource interface {
+	Next(ctx context.Context) (Event, error)
+}
+
+// ChannelSource is an in-process source fed through a channel (e.g. by another component or a test).
+// Closing the channel ends the source.
+type ChannelSource struct {
+	c <-chan Event
+}
+
+func NewChannelSource(c <-chan Event) *ChannelSource {
+	return &ChannelSource{c: c}
+}
+
+func (s *ChannelSource) Next(ctx context.Context) (Event, error) {
+	select {
+	case <-ctx.Done():
+		return Event{}, ctx.Err()
+	case e, ok := <-s.c:
+		if !ok {
+			return Event{}, io.EOF
+		}
+		return e, nil
+	}
+}
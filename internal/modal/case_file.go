@@ -1,16 +1,212 @@
 package modal
 
-import "time"
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
 
+// CaseFile is the single view of an order's context for ops: the flat summary fields drive the playbook,
+// and the sections below are the evidence an agent would otherwise gather from several tools.
 type CaseFile struct {
 	OrderID        string         `json:"orderId"`
 	IssueType      IssueType      `json:"issueType"`
 	BuyerEmail     string         `json:"buyerEmail"`
 	TransferStatus TransferStatus `json:"transferStatus"`
+	PaymentStatus  PaymentStatus  `json:"paymentStatus,omitempty"`
 	AttemptCount   int            `json:"attemptCount"`
 	GeneratedAt    time.Time      `json:"generatedAt"`
+	// Event is the report that started the workflow.
+	Event BrokenOrderEvent `json:"event"`
+	// Classification is how IssueType was decided (see internal/classify).
+	Classification Classification `json:"classification"`
+
+	// Evidence sections.
+	Order             OrderDetails      `json:"order"`
+	SupplierComms     []SupplierMessage `json:"supplierComms,omitempty"`
+	TransferAttempts  []ActionAttemp    `json:"transferAttempts,omitempty"`
+	RefundEligibility RefundEligibility `json:"refundEligibility"`
+	// ExpectedSeats are the seats sold (from the Order service); DeliveredSeats are the seats the buyer
+	// received (from the Transfer service).
+	ExpectedSeats  []Seat `json:"expectedSeats,omitempty"`
+	DeliveredSeats []Seat `json:"deliveredSeats,omitempty"`
+	// Discrepancy is how the two differ, once a CompareSeats step compared them.
+	Discrepancy *SeatDiscrepancy `json:"discrepancy,omitempty"`
+	// TransferBlockedUntil is when the venue starts allowing transfers for the event, if it does not yet.
+	TransferBlockedUntil *time.Time `json:"transferBlockedUntil,omitempty"`
+	// Timeline merges every dated event above in chronological order.
+	Timeline []TimelineEntry `json:"timeline,omitempty"`
+	// Sections records whether each context source could be fetched (see Section* constants).
+	Sections map[string]SectionStatus `json:"sections,omitempty"`
+
+	// Children are the child workflows started by the playbook, with their last known status.
+	Children []ChildWorkflow `json:"children,omitempty"`
+	// Replacement is the replacement seats held for the order, once a SourceReplacement child reserved them.
+	Replacement *Reservation `json:"replacement,omitempty"`
+	// ParentWorkflowID is set on the case file of a child workflow.
+	ParentWorkflowID string `json:"parentWorkflowId,omitempty"`
+}
+
+// Context sources gathered into the case file.
+const (
+	SectionOrder         = "order"
+	SectionTransfer      = "transfer"
+	SectionSupplierComms = "supplierComms"
+	SectionPayment       = "payment"
+)
+
+type SectionStatus string
+
+const (
+	SectionAvailable   SectionStatus = "AVAILABLE"
+	SectionUnavailable SectionStatus = "UNAVAILABLE"
+)
+
+// TransferContext is what the Transfer service knows about an order.
+type TransferContext struct {
+	Status   TransferStatus `json:"status"`
+	Attempts []ActionAttemp `json:"attempts,omitempty"`
+	// DeliveredSeats are the seats transferred to the buyer so far.
+	DeliveredSeats []Seat `json:"deliveredSeats,omitempty"`
+	// BlockedUntil is when the venue unlocks transfers for the event; nil if they are not blocked.
+	BlockedUntil *time.Time `json:"blockedUntil,omitempty"`
+}
+
+// PaymentContext is what the Payment service knows about an order.
+type PaymentContext struct {
+	Authorization     PaymentAuthResult `json:"authorization"`
+	RefundEligibility RefundEligibility `json:"refundEligibility"`
+}
+
+// OrderDetails is the purchase record returned by the Order service.
+type OrderDetails struct {
+	OrderID     string    `json:"orderId"`
+	BuyerEmail  string    `json:"buyerEmail"`
+	AmountCents int64     `json:"amountCents"`
+	Currency    string    `json:"currency"`
+	PurchasedAt time.Time `json:"purchasedAt"`
+	EventName   string    `json:"eventName"`
+	EventDate   time.Time `json:"eventDate"`
+	Venue       string    `json:"venue"`
+	ListingID   string    `json:"listingId"`
+	SupplierID  string    `json:"supplierId"`
+	Seats       []Seat    `json:"seats,omitempty"`
+}
+
+type Seat struct {
+	Section string `json:"section"`
+	Row     string `json:"row"`
+	Number  string `json:"number"`
+}
+
+func (s Seat) String() string {
+	return fmt.Sprintf("%s-%s-%s", s.Section, s.Row, s.Number)
+}
+
+// SeatDiscrepancy is how the seats delivered to the buyer differ from the seats sold.
+type SeatDiscrepancy struct {
+	// Missing were sold but not delivered; Unexpected were delivered but not sold.
+	Missing    []Seat `json:"missing,omitempty"`
+	Unexpected []Seat `json:"unexpected,omitempty"`
+	// ValueCents is the share of the order amount paid for the Missing seats.
+	ValueCents int64  `json:"valueCents"`
+	Currency   string `json:"currency"`
+}
+
+// CompareSeats compares the seats sold with the seats delivered. A seat delivered twice counts once.
+func CompareSeats(expected, delivered []Seat) SeatDiscrepancy {
+	var d SeatDiscrepancy
+	for _, s := range expected {
+		if !slices.Contains(delivered, s) {
+			d.Missing = append(d.Missing, s)
+		}
+	}
+	for _, s := range delivered {
+		if !slices.Contains(expected, s) && !slices.Contains(d.Unexpected, s) {
+			d.Unexpected = append(d.Unexpected, s)
+		}
+	}
+	return d
+}
+
+// CompareSeats compares the case file's expected and delivered seats and values the missing ones at their
+// share of the order amount. It is a pure function of the case file so it is safe to call from workflow code.
+func (cf *CaseFile) CompareSeats() SeatDiscrepancy {
+	d := CompareSeats(cf.ExpectedSeats, cf.DeliveredSeats)
+	d.Currency = cf.Order.Currency
+	if n := int64(len(cf.ExpectedSeats)); n > 0 {
+		d.ValueCents = cf.Order.AmountCents * int64(len(d.Missing)) / n
+	}
+	return d
 }
 
+// IssueType is the issue the discrepancy amounts to: wrong seats are a mismatch, seats that are only missing
+// a partial fulfillment. It is empty when every seat sold was delivered.
+func (d SeatDiscrepancy) IssueType() IssueType {
+	switch {
+	case len(d.Unexpected) > 0:
+		return IssueSeatMismatch
+	case len(d.Missing) > 0:
+		return IssuePartialFulfillment
+	default:
+		return ""
+	}
+}
+
+// Seat correction results returned by the Supplier service.
+const (
+	CorrectionCorrected = "CORRECTED"
+	CorrectionDeclined  = "DECLINED"
+)
+
+// SupplierMessage is one entry in the supplier communication history for an order.
+type SupplierMessage struct {
+	At        time.Time `json:"at"`
+	Direction string    `json:"direction"` // "OUTBOUND" (us -> supplier) or "INBOUND"
+	Body      string    `json:"body"`
+}
+
+// RefundEligibility is the Payment service's refund policy decision for an order.
+type RefundEligibility struct {
+	Eligible       bool   `json:"eligible"`
+	MaxRefundCents int64  `json:"maxRefundCents"`
+	Policy         string `json:"policy"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// RefundProposal is the refund the Payment service computes for an order under its refund policy.
+type RefundProposal struct {
+	AmountCents int64  `json:"amountCents"`
+	Currency    string `json:"currency"`
+	Policy      string `json:"policy"`
+	// Rationale explains the amount to the approver.
+	Rationale string `json:"rationale"`
+}
+
+// RefundResult is a refund issued by the Payment service.
+type RefundResult struct {
+	RefundID    string `json:"refundId"`
+	AmountCents int64  `json:"amountCents"`
+	Currency    string `json:"currency"`
+	Status      string `json:"status"`
+}
+
+// RefundResult statuses.
+const (
+	RefundIssued = "ISSUED"
+	RefundVoided = "VOIDED"
+)
+
+// PaymentAuthResult is the outcome of a payment (re-)authorization.
+type PaymentAuthResult struct {
+	Status      PaymentStatus `json:"status"`
+	DeclineCode string        `json:"declineCode,omitempty"`
+}
+
+// ActionAttemp is one execution of a side-effecting action (transfer retry, refund, supplier ping, ...).
+// The workflow records one per attempt in its ledger before calling the downstream system, and the
+// adapter uses IdempotencyKey so a retried activity never repeats the side effect.
 type ActionAttemp struct {
 	AttemptID      string    `json:"attemptId"`
 	OrderID        string    `json:"orderId"`
@@ -18,4 +214,73 @@ type ActionAttemp struct {
 	IdempotencyKey string    `json:"idempotencyKey"`
 	AttemptedAt    time.Time `json:"attemptedAt"`
 	Result         string    `json:"result"`
+	// StepID and Attempt locate the attempt in the playbook run. Empty for attempts reported by
+	// downstream systems that were not made by a workflow.
+	StepID  string `json:"stepId,omitempty"`
+	Attempt int    `json:"attempt,omitempty"`
+}
+
+// Ledger results for an attempt that has not completed or whose activity failed.
+const (
+	AttemptPending = "PENDING"
+	AttemptFailed  = "FAILED"
+)
+
+// TimelineEntry is one dated event in the case file timeline.
+type TimelineEntry struct {
+	At      time.Time `json:"at"`
+	Source  string    `json:"source"` // ORDER, SUPPLIER, TRANSFER
+	Summary string    `json:"summary"`
+}
+
+// BuildTimeline merges the dated evidence sections into cf.Timeline, oldest first.
+// It is a pure function of the case file so it is safe to call from workflow code.
+func (cf *CaseFile) BuildTimeline() {
+	var tl []TimelineEntry
+	if !cf.Order.PurchasedAt.IsZero() {
+		tl = append(tl, TimelineEntry{
+			At:      cf.Order.PurchasedAt,
+			Source:  "ORDER",
+			Summary: fmt.Sprintf("order purchased (%d seats, %s)", len(cf.Order.Seats), FormatAmount(cf.Order.AmountCents, cf.Order.Currency)),
+		})
+	}
+	for _, m := range cf.SupplierComms {
+		tl = append(tl, TimelineEntry{
+			At:      m.At,
+			Source:  "SUPPLIER",
+			Summary: fmt.Sprintf("%s: %s", m.Direction, m.Body),
+		})
+	}
+	for _, a := range cf.TransferAttempts {
+		tl = append(tl, TimelineEntry{
+			At:      a.AttemptedAt,
+			Source:  "TRANSFER",
+			Summary: fmt.Sprintf("%s => %s", a.ActionType, a.Result),
+		})
+	}
+	if cf.TransferBlockedUntil != nil {
+		tl = append(tl, TimelineEntry{
+			At:      *cf.TransferBlockedUntil,
+			Source:  "TRANSFER",
+			Summary: "venue unlocks transfers",
+		})
+	}
+	if !cf.Order.EventDate.IsZero() {
+		tl = append(tl, TimelineEntry{
+			At:      cf.Order.EventDate,
+			Source:  "ORDER",
+			Summary: "event starts: " + cf.Order.EventName,
+		})
+	}
+	sort.SliceStable(tl, func(i, j int) bool { return tl[i].At.Before(tl[j].At) })
+	cf.Timeline = tl
+}
+
+// FormatAmount renders minor units as a decimal amount, e.g. 12050 USD => "120.50 USD".
+func FormatAmount(cents int64, currency string) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, cents/100, cents%100, currency)
 }
@@ -0,0 +1,47 @@
+package modal
+
+import "time"
+
+// ChildStatus is the state of a child workflow as its parent last saw it.
+type ChildStatus string
+
+const (
+	ChildRunning   ChildStatus = "RUNNING"
+	ChildCompleted ChildStatus = "COMPLETED"
+	ChildFailed    ChildStatus = "FAILED"
+	ChildCancelled ChildStatus = "CANCELLED"
+)
+
+// ChildWorkflow is a child workflow a playbook step started. The parent lists them in its case file.
+type ChildWorkflow struct {
+	WorkflowID string      `json:"workflowId"`
+	RunID      string      `json:"runId,omitempty"`
+	Type       string      `json:"type"`
+	StepID     string      `json:"stepId"`
+	Status     ChildStatus `json:"status"`
+	// Outcome is the child's result once it completed; Error is why it failed.
+	Outcome   string    `json:"outcome,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	ClosedAt  time.Time `json:"closedAt,omitempty"`
+}
+
+// ChildRequest is the input of every child workflow.
+type ChildRequest struct {
+	ParentWorkflowID string `json:"parentWorkflowId"`
+	StepID           string `json:"stepId"`
+	// Order is the parent's view of the order; children need not fetch it again.
+	Order OrderDetails `json:"order"`
+	// Params are the playbook step's params.
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// ChildResult is what a child workflow returns to its parent.
+type ChildResult struct {
+	Outcome string `json:"outcome"`
+	Message string `json:"message,omitempty"`
+	// Reservation is the replacement seats a SourceReplacement child is holding.
+	Reservation *Reservation `json:"reservation,omitempty"`
+	// Actions is the child's attempt ledger; the parent adds it to its own.
+	Actions []ActionAttemp `json:"actions,omitempty"`
+}
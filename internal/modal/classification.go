@@ -0,0 +1,14 @@
+package modal
+
+// Classification is a classifier's verdict on a case file: which playbook should handle the order,
+// which tier should own its human tasks, and how sure the classifier is.
+type Classification struct {
+	IssueType IssueType `json:"issueType"`
+	Tier      string    `json:"tier"`
+	// Confidence is between 0 and 1. Below the workflow's threshold the order goes to a TRIAGE task.
+	Confidence float64 `json:"confidence"`
+	// Reasons lists the signals behind the verdict, for the audit log and the triage task.
+	Reasons []string `json:"reasons,omitempty"`
+	// Classifier names the implementation that produced the verdict.
+	Classifier string `json:"classifier"`
+}
@@ -0,0 +1,50 @@
+package modal
+
+import (
+	"fmt"
+	"time"
+)
+
+// BrokenOrderEvent is the report that starts a ResolveBrokenOrder workflow. One order can be reported
+// several times (a new incident after an earlier one was resolved), so executions are keyed by EventID
+// and correlated to the order through the OrderId search attribute.
+type BrokenOrderEvent struct {
+	// EventID is the producer's idempotency key. Redelivering the same event never starts a second workflow.
+	EventID string `json:"eventId"`
+	OrderID string `json:"orderId"`
+	// ReportedIssueType is the reporter's hint. The case file evidence can override it (see CaseFile.IssueType).
+	ReportedIssueType IssueType `json:"reportedIssueType,omitempty"`
+	// Source names the producer, e.g. "api", "ingest" or "starter".
+	Source     string    `json:"source,omitempty"`
+	Priority   Priority  `json:"priority,omitempty"`
+	ReceivedAt time.Time `json:"receivedAt"`
+}
+
+type Priority string
+
+const (
+	PriorityLow    Priority = "LOW"
+	PriorityNormal Priority = "NORMAL"
+	PriorityHigh   Priority = "HIGH"
+)
+
+// WorkflowID returns the ID of the workflow that handles this event.
+func (e BrokenOrderEvent) WorkflowID() string {
+	return "resolve-" + e.EventID
+}
+
+// Validate checks the fields a workflow cannot run without.
+func (e BrokenOrderEvent) Validate() error {
+	if e.EventID == "" {
+		return fmt.Errorf("eventId is required")
+	}
+	if e.OrderID == "" {
+		return fmt.Errorf("orderId is required")
+	}
+	switch e.Priority {
+	case "", PriorityLow, PriorityNormal, PriorityHigh:
+	default:
+		return fmt.Errorf("unknown priority %q", e.Priority)
+	}
+	return nil
+}
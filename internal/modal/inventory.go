@@ -0,0 +1,39 @@
+package modal
+
+import "time"
+
+// SeatListing is a set of seats for sale, from any supplier.
+type SeatListing struct {
+	ListingID  string `json:"listingId"`
+	SupplierID string `json:"supplierId"`
+	Seats      []Seat `json:"seats"`
+	PriceCents int64  `json:"priceCents"`
+	Currency   string `json:"currency"`
+}
+
+// Reservation holds a listing for an order until the seats are bought or released.
+type Reservation struct {
+	ReservationID string      `json:"reservationId"`
+	OrderID       string      `json:"orderId"`
+	Listing       SeatListing `json:"listing"`
+	Status        string      `json:"status"`
+	ExpiresAt     time.Time   `json:"expiresAt"`
+}
+
+// Reservation statuses.
+const (
+	ReservationHeld      = "HELD"
+	ReservationPurchased = "PURCHASED"
+	ReservationReleased  = "RELEASED"
+)
+
+// PriceBand bounds the total price of the listings a search returns. A zero MaxCents means no upper bound.
+type PriceBand struct {
+	MinCents int64 `json:"minCents"`
+	MaxCents int64 `json:"maxCents"`
+}
+
+// Contains reports whether priceCents is within the band.
+func (b PriceBand) Contains(priceCents int64) bool {
+	return priceCents >= b.MinCents && (b.MaxCents == 0 || priceCents <= b.MaxCents)
+}
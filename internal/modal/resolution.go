@@ -0,0 +1,69 @@
+package modal
+
+import "slices"
+
+// Outcome is how a ResolveBrokenOrder workflow ended.
+type Outcome string
+
+const (
+	// OutcomeResolvedAutomatically: the playbook fixed the order without a human.
+	OutcomeResolvedAutomatically Outcome = "RESOLVED_AUTOMATICALLY"
+	// OutcomeEscalatedApproved: a human approved the escalated resolution (e.g. fixed the transfer by hand).
+	OutcomeEscalatedApproved Outcome = "ESCALATED_APPROVED"
+	// OutcomeRefunded: the buyer was refunded.
+	OutcomeRefunded Outcome = "REFUNDED"
+	// OutcomeAwaitingBuyerAction: the buyer was asked to act (e.g. update their payment method).
+	OutcomeAwaitingBuyerAction Outcome = "AWAITING_BUYER_ACTION"
+	// OutcomeRejected: the proposed resolution was rejected, by a human or by a task's SLA default.
+	OutcomeRejected Outcome = "REJECTED"
+	// OutcomeUnsupported: no playbook handles the issue type, so nothing was attempted.
+	OutcomeUnsupported Outcome = "UNSUPPORTED_ISSUE_TYPE"
+	// OutcomeManualFollowUp: the playbook ran out of options without a rejection (e.g. the order is not
+	// eligible for a refund); ops must follow up outside the workflow.
+	OutcomeManualFollowUp Outcome = "MANUAL_FOLLOW_UP"
+)
+
+// Outcomes lists every outcome a workflow can end with.
+var Outcomes = []Outcome{
+	OutcomeResolvedAutomatically, OutcomeEscalatedApproved, OutcomeRefunded, OutcomeAwaitingBuyerAction,
+	OutcomeRejected, OutcomeUnsupported, OutcomeManualFollowUp,
+}
+
+// legacyOutcomes maps results used before Outcome existed. Playbooks are recorded in workflow history when
+// loaded, so executions started earlier still finish with these.
+var legacyOutcomes = map[string]Outcome{
+	"PENDING_MANUAL_REVIEW": OutcomeManualFollowUp,
+	"ESCALATED_REJECTED":    OutcomeUnsupported,
+}
+
+// Known reports whether o is one of Outcomes.
+func (o Outcome) Known() bool {
+	return slices.Contains(Outcomes, o)
+}
+
+// ParseOutcome returns the outcome named by a playbook finish result, accepting legacy result names.
+func ParseOutcome(s string) (Outcome, bool) {
+	if o := Outcome(s); o.Known() {
+		return o, true
+	}
+	o, ok := legacyOutcomes[s]
+	return o, ok
+}
+
+// Who decided a resolution when it was not a named human.
+const (
+	DecidedByAutomation = "automation"
+	// DecidedBySLA means a task passed its hard deadline and its SLA default action was applied.
+	DecidedBySLA = "sla-default"
+)
+
+// Resolution is the result of a ResolveBrokenOrder workflow.
+type Resolution struct {
+	Outcome Outcome `json:"outcome"`
+	Reason  string  `json:"reason"`
+	// DecidedBy is whoever made the decision the outcome rests on: the decider(s) of the deciding task,
+	// DecidedBySLA, or DecidedByAutomation when no task was involved.
+	DecidedBy string `json:"decidedBy"`
+	// Actions is the workflow's action attempt ledger: every side effect it executed, in order.
+	Actions []ActionAttemp `json:"actions,omitempty"`
+}
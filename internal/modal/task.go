@@ -2,13 +2,64 @@ package modal
 
 import "time"
 
+// Human task types created by the built-in playbooks.
+const (
+	TaskRetryTransfer  = "RETRY_TRANSFER"
+	TaskRefundApproval = "REFUND_APPROVAL"
+	// TaskTriage asks a human to confirm the issue type when the classifier is not confident.
+	TaskTriage = "TRIAGE"
+)
+
+// TaskStatus is the lifecycle state of a human task.
+type TaskStatus string
+
+const (
+	TaskOpen     TaskStatus = "OPEN"
+	TaskClaimed  TaskStatus = "CLAIMED"
+	TaskApproved TaskStatus = "APPROVED"
+	TaskRejected TaskStatus = "REJECTED"
+	TaskExpired  TaskStatus = "EXPIRED"
+)
+
+// Support tiers that own a task. Tasks start at the tier the order was classified with (usually TIER1)
+// and move up when their SLA is missed.
+const (
+	Tier1 = "TIER1"
+	Tier2 = "TIER2"
+)
+
+// Active reports whether the task still awaits a decision.
+func (s TaskStatus) Active() bool {
+	return s == TaskOpen || s == TaskClaimed
+}
+
 type HumanTask struct {
-	ID        string    `json:"id"`
-	OrderID   string    `json:"orderId"`
-	Type      string    `json:"type"`
-	Title     string    `json:"title"`
-	Reason    string    `json:"reason"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID        string     `json:"id"`
+	OrderID   string     `json:"orderId"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	Reason    string     `json:"reason"`
+	CreatedAt time.Time  `json:"createdAt"`
+	Status    TaskStatus `json:"status,omitempty"`
+	// Tier is the support tier that owns the task.
+	Tier string `json:"tier,omitempty"`
+	// DueAt is the SLA target and DeadlineAt the hard deadline, if the task type has an SLA.
+	DueAt      time.Time `json:"dueAt,omitempty"`
+	DeadlineAt time.Time `json:"deadlineAt,omitempty"`
+	// ClaimedBy is the agent working on the task, if it has been claimed.
+	ClaimedBy string `json:"claimedBy,omitempty"`
+	// ClosedAt is when the task was approved, rejected or expired.
+	ClosedAt time.Time `json:"closedAt,omitempty"`
+	// DecidedBy is who closed the task: its approvers, the rejecting decider, or DecidedBySLA.
+	DecidedBy string `json:"decidedBy,omitempty"`
+	// RequiredApprovals is how many distinct deciders must approve; 0 and 1 both mean one.
+	RequiredApprovals int `json:"requiredApprovals,omitempty"`
+	// Approvals collects the approving decisions received so far.
+	Approvals []TaskDecision `json:"approvals,omitempty"`
+	// Refund is the proposed refund for REFUND_APPROVAL tasks.
+	Refund *RefundProposal `json:"refund,omitempty"`
+	// Classification is the classifier's suggestion for TRIAGE tasks.
+	Classification *Classification `json:"classification,omitempty"`
 }
 
 type TaskDecision struct {
@@ -17,6 +68,28 @@ type TaskDecision struct {
 	Notes     string    `json:"notes"`
 	DecidedAt time.Time `json:"decidedAt"`
 	Decider   string    `json:"decider"`
+	// IssueType overrides the suggested issue type when approving a TRIAGE task.
+	IssueType IssueType `json:"issueType,omitempty"`
+}
+
+// DecisionResult is returned to the caller of a synchronous task decision.
+type DecisionResult struct {
+	TaskID            string     `json:"taskId"`
+	TaskStatus        TaskStatus `json:"taskStatus"`
+	Approvals         int        `json:"approvals"`
+	RequiredApprovals int        `json:"requiredApprovals"`
+	// OpenTasks lists the workflow's tasks still awaiting a decision after this one was applied.
+	OpenTasks []string `json:"openTasks,omitempty"`
+	// WorkflowStatus is the workflow's ResolutionStatus once the decision was applied: IN_PROGRESS, FAILED or the
+	// outcome the workflow resolved with.
+	WorkflowStatus string `json:"workflowStatus"`
+}
+
+// TaskClaim assigns an open task to an agent so others know it is being worked on.
+type TaskClaim struct {
+	TaskID    string    `json:"taskId"`
+	Claimant  string    `json:"claimant"`
+	ClaimedAt time.Time `json:"claimedAt"`
 }
 
 type AuditEvent struct {
@@ -1,16 +1,46 @@
 package modal
 
+import "slices"
+
 type IssueType string
 
 const (
 	IssueTransferFailed IssueType = "TRANSFER_FAILED"
-	IssuePaymentFailed IssueType = "PAYMENT_FAILED"
+	IssuePaymentFailed  IssueType = "PAYMENT_FAILED"
+	// IssueSupplierCannotFulfill: the supplier cannot deliver the seats sold, so replacements must be sourced.
+	IssueSupplierCannotFulfill IssueType = "SUPPLIER_CANNOT_FULFILL"
+	// IssueSeatMismatch: the buyer received seats other than the ones sold (e.g. the wrong section).
+	IssueSeatMismatch IssueType = "SEAT_MISMATCH"
+	// IssuePartialFulfillment: the buyer received only some of the seats sold.
+	IssuePartialFulfillment IssueType = "PARTIAL_FULFILLMENT"
+	// IssueTransferBlocked: the venue does not allow transfers yet (typically until 48h before the event).
+	IssueTransferBlocked IssueType = "TRANSFER_BLOCKED"
 )
 
+// IssueTypes lists every issue type the service recognises.
+var IssueTypes = []IssueType{
+	IssueTransferFailed, IssuePaymentFailed, IssueSupplierCannotFulfill, IssueSeatMismatch, IssuePartialFulfillment,
+	IssueTransferBlocked,
+}
+
+// Known reports whether t is one of IssueTypes.
+func (t IssueType) Known() bool {
+	return slices.Contains(IssueTypes, t)
+}
+
 type TransferStatus string
 
 const (
 	TransferNotAccepted TransferStatus = "NOT_ACCEPTED"
-	TransferAccepted TransferStatus = "ACCEPTED"
+	TransferAccepted    TransferStatus = "ACCEPTED"
+	// TransferCancelled is the result of a re-sent transfer that was withdrawn before the buyer accepted it.
+	TransferCancelled TransferStatus = "CANCELLED"
 )
 
+type PaymentStatus string
+
+const (
+	PaymentAuthorized   PaymentStatus = "AUTHORIZED"
+	PaymentSoftDeclined PaymentStatus = "SOFT_DECLINE" // transient (e.g. insufficient funds); worth retrying
+	PaymentHardDeclined PaymentStatus = "HARD_DECLINE" // non-recoverable (e.g. stolen card); needs a human
+)
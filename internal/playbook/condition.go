@@ -0,0 +1,49 @@
+package playbook
+
+import (
+	"fmt"
+	"strings"
+)
+
+// condition is a parsed "<field> <op> <value>" expression.
+// Only equality operators are supported for now; that covers the status checks playbooks need
+// without turning the config into a programming language.
+type condition struct {
+	field string
+	op    string
+	value string
+}
+
+func parseCondition(expr string) (condition, error) {
+	for _, op := range []string{"==", "!="} {
+		if left, right, ok := strings.Cut(expr, op); ok {
+			c := condition{
+				field: strings.TrimSpace(left),
+				op:    op,
+				value: strings.Trim(strings.TrimSpace(right), `"`),
+			}
+			if c.field == "" {
+				return condition{}, fmt.Errorf("condition %q: missing field", expr)
+			}
+			return c, nil
+		}
+	}
+	return condition{}, fmt.Errorf("condition %q: expected \"<field> == <value>\" or \"<field> != <value>\"", expr)
+}
+
+// EvalCondition evaluates expr using lookup to resolve field names.
+// Unknown fields are an error so typos in playbooks surface instead of silently evaluating false.
+func EvalCondition(expr string, lookup func(field string) (string, bool)) (bool, error) {
+	c, err := parseCondition(expr)
+	if err != nil {
+		return false, err
+	}
+	v, ok := lookup(c.field)
+	if !ok {
+		return false, fmt.Errorf("condition %q: unknown field %q", expr, c.field)
+	}
+	if c.op == "==" {
+		return v == c.value, nil
+	}
+	return v != c.value, nil
+}
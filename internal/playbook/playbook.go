@@ -0,0 +1,512 @@
+package playbook
+
+import (
+	"broken-order-service/internal/modal"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// StepKind identifies how the workflow interprets a playbook step.
+type StepKind string
+
+const (
+	// KindAction runs a named action (an activity call), optionally with bounded retries.
+	KindAction StepKind = "action"
+	// KindCondition evaluates a condition against the workflow state and yields "true" or "false".
+	KindCondition StepKind = "condition"
+	// KindHumanGate creates a human task and waits for a decision ("approved" or "rejected").
+	KindHumanGate StepKind = "human_gate"
+	// KindRefund computes a refund, waits for a REFUND_APPROVAL decision and issues it once approved.
+	// It yields "refunded", "rejected" or "not_eligible".
+	KindRefund StepKind = "refund"
+	// KindParallel runs its branch steps concurrently and waits for all of them. Branch steps' own "on"
+	// transitions are ignored; the first branch's outcome becomes the parallel step's outcome.
+	KindParallel StepKind = "parallel"
+	// KindChildWorkflow starts a child workflow (one of the Child* names) and waits for it. Its outcome is the
+	// child's: "reserved", "needs_approval" or "not_found" for SourceReplacement, SENT for NotifyBuyer (like the
+	// NotifyBuyer action).
+	KindChildWorkflow StepKind = "child_workflow"
+	// KindWait sleeps on a durable timer until the case file time named by Until, then yields "elapsed". It yields
+	// "elapsed" right away when that time is unset or has passed.
+	KindWait StepKind = "wait"
+	// KindFinish ends the workflow with the given result.
+	KindFinish StepKind = "finish"
+)
+
+// Actions the workflow knows how to execute. Playbooks can only reference these names.
+const (
+	ActionRetryTransfer      = "RetryTransfer"
+	ActionReauthorizePayment = "ReauthorizePayment"
+	ActionNotifyBuyer        = "NotifyBuyer"
+	// NotifyExpectedDelivery sends the template with the time transfers unlock as the expected delivery time.
+	ActionNotifyExpectedDelivery = "NotifyExpectedDelivery"
+	ActionPingSupplier           = "PingSupplier"
+	// The replacement actions act on the seats a SourceReplacement child reserved. PurchaseReplacement yields
+	// PURCHASED, TransferReplacement the transfer status and ReleaseReplacement RELEASED.
+	ActionPurchaseReplacement = "PurchaseReplacement"
+	ActionTransferReplacement = "TransferReplacement"
+	ActionReleaseReplacement  = "ReleaseReplacement"
+	// CompareSeats compares the seats delivered with the seats sold and yields "match", "mismatch" or "partial".
+	// RequestSeatCorrection asks the supplier to fix the discrepancy and yields CORRECTED or DECLINED.
+	ActionCompareSeats          = "CompareSeats"
+	ActionRequestSeatCorrection = "RequestSeatCorrection"
+)
+
+// knownActions lists each action with the params it requires.
+var knownActions = map[string][]string{
+	ActionRetryTransfer:          nil,
+	ActionReauthorizePayment:     nil,
+	ActionNotifyBuyer:            {"template"},
+	ActionNotifyExpectedDelivery: {"template"},
+	ActionPingSupplier:           {"message"},
+	ActionPurchaseReplacement:    nil,
+	ActionTransferReplacement:    nil,
+	ActionReleaseReplacement:     nil,
+	ActionCompareSeats:           nil,
+	ActionRequestSeatCorrection:  nil,
+}
+
+// Child workflows a KindChildWorkflow step can start. They run as separate executions with their own audit logs.
+const (
+	// ChildSourceReplacement searches for comparable seats and reserves the cheapest. Optional params:
+	// searchEvery and searchFor (Go durations, default 1h and 24h) keep searching while nothing is found;
+	// priceBandPercent (default 25) limits the search to listings within that percentage of what the buyer
+	// paid; a reservation costing more than approvalAbovePercent (default 10) over it needs approval.
+	ChildSourceReplacement = "SourceReplacement"
+	// ChildNotifyBuyer sends the buyer a templated notification, retrying delivery for up to a day.
+	ChildNotifyBuyer = "NotifyBuyer"
+)
+
+// knownChildWorkflows lists each child workflow with the params it requires.
+var knownChildWorkflows = map[string][]string{
+	ChildSourceReplacement: nil,
+	ChildNotifyBuyer:       {"template"},
+}
+
+// Outcomes produced by built-in step kinds.
+const (
+	OutcomeTrue     = "true"
+	OutcomeFalse    = "false"
+	OutcomeApproved = "approved"
+	OutcomeRejected = "rejected"
+
+	OutcomeRefunded    = "refunded"
+	OutcomeNotEligible = "not_eligible"
+
+	OutcomeReserved      = "reserved"
+	OutcomeNeedsApproval = "needs_approval"
+	OutcomeNotFound      = "not_found"
+
+	OutcomeMatch    = "match"
+	OutcomeMismatch = "mismatch"
+	OutcomePartial  = "partial"
+
+	OutcomeElapsed = "elapsed"
+)
+
+// waitFields are the case file times a KindWait step can wait for.
+var waitFields = []string{"transferBlockedUntil"}
+
+// Playbook maps an issue type to an ordered list of steps.
+// Execution starts at the first step and follows each step's "on" transitions;
+// when no transition matches, it falls through to the next step in the list.
+type Playbook struct {
+	IssueType   modal.IssueType `json:"issueType" yaml:"issueType"`
+	Version     int             `json:"version" yaml:"version"`
+	Description string          `json:"description,omitempty" yaml:"description,omitempty"`
+	Steps       []Step          `json:"steps" yaml:"steps"`
+	// SLAs bound how long human tasks may stay open, keyed by task type. Task types without an SLA wait indefinitely.
+	SLAs map[string]SLA `json:"slas,omitempty" yaml:"slas,omitempty"`
+}
+
+// SLA is the response-time policy for one human task type. Durations are Go duration strings
+// measured from task creation.
+type SLA struct {
+	// Target is the expected time to a decision. A reminder is recorded at half of it and the task is
+	// escalated to EscalateTo when it passes.
+	Target string `json:"target" yaml:"target"`
+	// EscalateTo is the tier that owns the task after Target. Defaults to TIER2.
+	EscalateTo string `json:"escalateTo,omitempty" yaml:"escalateTo,omitempty"`
+	// HardDeadline auto-applies DefaultAction ("approved" or "rejected") if nobody has decided by then.
+	// Empty means the task stays open until decided.
+	HardDeadline  string `json:"hardDeadline,omitempty" yaml:"hardDeadline,omitempty"`
+	DefaultAction string `json:"defaultAction,omitempty" yaml:"defaultAction,omitempty"`
+}
+
+type Step struct {
+	ID   string   `json:"id" yaml:"id"`
+	Kind StepKind `json:"kind" yaml:"kind"`
+
+	// Action is the action name for KindAction steps.
+	Action string `json:"action,omitempty" yaml:"action,omitempty"`
+	// Params are action-specific arguments, e.g. the notification template for NotifyBuyer.
+	Params map[string]string `json:"params,omitempty" yaml:"params,omitempty"`
+	// Retry bounds how often an action is repeated. Nil means a single attempt.
+	Retry *Retry `json:"retry,omitempty" yaml:"retry,omitempty"`
+
+	// Condition is the expression for KindCondition steps, e.g. "transferStatus == ACCEPTED".
+	Condition string `json:"condition,omitempty" yaml:"condition,omitempty"`
+
+	// Task describes the human task for KindHumanGate steps (and optionally KindRefund steps).
+	Task *TaskSpec `json:"task,omitempty" yaml:"task,omitempty"`
+
+	// Refund configures KindRefund steps.
+	Refund *RefundSpec `json:"refund,omitempty" yaml:"refund,omitempty"`
+
+	// Workflow is the child workflow a KindChildWorkflow step starts; it receives the step's Params.
+	Workflow string `json:"workflow,omitempty" yaml:"workflow,omitempty"`
+
+	// Until names the case file time a KindWait step waits for (one of waitFields).
+	Until string `json:"until,omitempty" yaml:"until,omitempty"`
+
+	// Branches lists the step IDs a KindParallel step runs concurrently.
+	Branches []string `json:"branches,omitempty" yaml:"branches,omitempty"`
+
+	// Result, Message and AuditKind describe how a KindFinish step ends the workflow. Result is a modal.Outcome,
+	// and Message becomes the resolution's reason.
+	Result    string `json:"result,omitempty" yaml:"result,omitempty"`
+	Message   string `json:"message,omitempty" yaml:"message,omitempty"`
+	AuditKind string `json:"auditKind,omitempty" yaml:"auditKind,omitempty"`
+
+	// On maps a step outcome to the ID of the next step. "*" matches any outcome.
+	On map[string]string `json:"on,omitempty" yaml:"on,omitempty"`
+}
+
+// Retry bounds the attempts of an action step.
+// The action is repeated while its outcome is listed in RetryOn, up to MaxAttempts.
+type Retry struct {
+	MaxAttempts int      `json:"maxAttempts" yaml:"maxAttempts"`
+	RetryOn     []string `json:"retryOn,omitempty" yaml:"retryOn,omitempty"`
+	// Backoff is a Go duration string (e.g. "30s") slept between attempts.
+	Backoff string `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+}
+
+type TaskSpec struct {
+	Type   string `json:"type" yaml:"type"`
+	Title  string `json:"title" yaml:"title"`
+	Reason string `json:"reason" yaml:"reason"`
+}
+
+// RefundSpec configures the approval of a KindRefund step.
+type RefundSpec struct {
+	// SecondApprovalAboveCents requires a second, distinct approver for refunds above this amount.
+	// 0 means a single approval is always enough.
+	SecondApprovalAboveCents int64 `json:"secondApprovalAboveCents,omitempty" yaml:"secondApprovalAboveCents,omitempty"`
+	// Basis limits what the refund covers. Empty refunds what the policy allows; RefundBasisSeats caps that at
+	// the value of the seats the buyer did not get as sold (see modal.CaseFile.CompareSeats).
+	Basis string `json:"basis,omitempty" yaml:"basis,omitempty"`
+}
+
+// RefundBasisSeats refunds only the seats missing from the delivery.
+const RefundBasisSeats = "seats"
+
+// RequiredApprovals returns how many distinct approvers a refund of amountCents needs.
+func (r *RefundSpec) RequiredApprovals(amountCents int64) int {
+	if r != nil && r.SecondApprovalAboveCents > 0 && amountCents > r.SecondApprovalAboveCents {
+		return 2
+	}
+	return 1
+}
+
+// TargetDuration returns the parsed target. Validate guarantees it parses.
+func (s SLA) TargetDuration() time.Duration {
+	d, _ := time.ParseDuration(s.Target)
+	return d
+}
+
+// HardDeadlineDuration returns the parsed hard deadline, or 0 if none is set.
+func (s SLA) HardDeadlineDuration() time.Duration {
+	if s.HardDeadline == "" {
+		return 0
+	}
+	d, _ := time.ParseDuration(s.HardDeadline)
+	return d
+}
+
+func (s SLA) validate() error {
+	target, err := time.ParseDuration(s.Target)
+	if err != nil || target <= 0 {
+		return fmt.Errorf("target must be a positive duration")
+	}
+	if s.HardDeadline == "" {
+		if s.DefaultAction != "" {
+			return fmt.Errorf("defaultAction requires hardDeadline")
+		}
+		return nil
+	}
+	deadline, err := time.ParseDuration(s.HardDeadline)
+	if err != nil || deadline < target {
+		return fmt.Errorf("hardDeadline must be a duration no shorter than target")
+	}
+	if s.DefaultAction != OutcomeApproved && s.DefaultAction != OutcomeRejected {
+		return fmt.Errorf("defaultAction must be %q or %q", OutcomeApproved, OutcomeRejected)
+	}
+	return nil
+}
+
+// Empty reports whether no playbook is configured (zero value).
+func (p Playbook) Empty() bool {
+	return len(p.Steps) == 0
+}
+
+// Next returns the step ID to run after this step produced outcome, or "" to fall through.
+func (s Step) Next(outcome string) string {
+	if next, ok := s.On[outcome]; ok {
+		return next
+	}
+	return s.On["*"]
+}
+
+// ShouldRetry reports whether another attempt is allowed after the given attempt produced outcome.
+func (r *Retry) ShouldRetry(attempt int, outcome string) bool {
+	if r == nil || attempt >= r.MaxAttempts {
+		return false
+	}
+	for _, o := range r.RetryOn {
+		if o == outcome {
+			return true
+		}
+	}
+	return false
+}
+
+// BackoffDuration returns the parsed backoff. Validate guarantees it parses.
+func (r *Retry) BackoffDuration() time.Duration {
+	if r == nil || r.Backoff == "" {
+		return 0
+	}
+	d, _ := time.ParseDuration(r.Backoff)
+	return d
+}
+
+// Validate checks the playbook for structural errors so bad config is rejected at load time
+// rather than in the middle of a workflow.
+func (p Playbook) Validate() error {
+	if p.IssueType == "" {
+		return fmt.Errorf("playbook: issueType is required")
+	}
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("playbook %s: at least one step is required", p.IssueType)
+	}
+
+	for taskType, sla := range p.SLAs {
+		if err := sla.validate(); err != nil {
+			return fmt.Errorf("playbook %s: sla %s: %w", p.IssueType, taskType, err)
+		}
+	}
+
+	ids := make(map[string]bool, len(p.Steps))
+	kinds := make(map[string]StepKind, len(p.Steps))
+	for _, s := range p.Steps {
+		if s.ID == "" {
+			return fmt.Errorf("playbook %s: step id is required", p.IssueType)
+		}
+		if ids[s.ID] {
+			return fmt.Errorf("playbook %s: duplicate step id %q", p.IssueType, s.ID)
+		}
+		ids[s.ID] = true
+		kinds[s.ID] = s.Kind
+	}
+
+	for _, s := range p.Steps {
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("playbook %s: step %q: %w", p.IssueType, s.ID, err)
+		}
+		for outcome, next := range s.On {
+			if !ids[next] {
+				return fmt.Errorf("playbook %s: step %q: outcome %q points to unknown step %q", p.IssueType, s.ID, outcome, next)
+			}
+		}
+		for _, b := range s.Branches {
+			switch kinds[b] {
+			case "":
+				return fmt.Errorf("playbook %s: step %q: unknown branch %q", p.IssueType, s.ID, b)
+			case KindFinish, KindParallel:
+				return fmt.Errorf("playbook %s: step %q: branch %q cannot be a %s step", p.IssueType, s.ID, b, kinds[b])
+			}
+		}
+	}
+	return p.validateTermination()
+}
+
+// validateTermination rejects playbooks that can run off the end of the step list or go round forever: every step
+// reachable from the first one must be able to reach a finish step.
+func (p Playbook) validateTermination() error {
+	index := make(map[string]int, len(p.Steps))
+	for i, s := range p.Steps {
+		index[s.ID] = i
+	}
+	next := func(i int) []int {
+		var out []int
+		for _, id := range p.Steps[i].On {
+			out = append(out, index[id])
+		}
+		if p.Steps[i].fallsThrough() {
+			out = append(out, i+1)
+		}
+		return out
+	}
+
+	reachable := make([]bool, len(p.Steps))
+	queue := []int{0}
+	reachable[0] = true
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, n := range next(i) {
+			if n == len(p.Steps) {
+				return fmt.Errorf("playbook %s: step %q can fall through past the last step", p.IssueType, p.Steps[i].ID)
+			}
+			if !reachable[n] {
+				reachable[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+
+	// A step can finish if it is a finish step or one of its successors can finish.
+	finishes := make([]bool, len(p.Steps))
+	for changed := true; changed; {
+		changed = false
+		for i, s := range p.Steps {
+			if finishes[i] {
+				continue
+			}
+			if s.Kind == KindFinish || slices.ContainsFunc(next(i), func(n int) bool { return n < len(p.Steps) && finishes[n] }) {
+				finishes[i], changed = true, true
+			}
+		}
+	}
+	for i, s := range p.Steps {
+		if reachable[i] && !finishes[i] {
+			return fmt.Errorf("playbook %s: step %q cannot reach a finish step", p.IssueType, s.ID)
+		}
+	}
+	return nil
+}
+
+// outcomes lists every outcome a step can yield, or nil when that depends on its action or child workflow.
+func (s Step) outcomes() []string {
+	switch s.Kind {
+	case KindCondition:
+		return []string{OutcomeTrue, OutcomeFalse}
+	case KindHumanGate:
+		return []string{OutcomeApproved, OutcomeRejected}
+	case KindRefund:
+		return []string{OutcomeRefunded, OutcomeRejected, OutcomeNotEligible}
+	case KindWait:
+		return []string{OutcomeElapsed}
+	}
+	return nil
+}
+
+// fallsThrough reports whether some outcome of s may match none of its "on" transitions.
+func (s Step) fallsThrough() bool {
+	if s.Kind == KindFinish {
+		return false
+	}
+	if _, ok := s.On["*"]; ok {
+		return false
+	}
+	known := s.outcomes()
+	if known == nil {
+		return true
+	}
+	for _, o := range known {
+		if _, ok := s.On[o]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Step) validate() error {
+	switch s.Kind {
+	case KindAction:
+		required, ok := knownActions[s.Action]
+		if !ok {
+			return fmt.Errorf("unknown action %q", s.Action)
+		}
+		for _, p := range required {
+			if s.Params[p] == "" {
+				return fmt.Errorf("action %s requires params.%s", s.Action, p)
+			}
+		}
+		if s.Retry != nil {
+			if s.Retry.MaxAttempts < 1 {
+				return fmt.Errorf("retry.maxAttempts must be >= 1")
+			}
+			if s.Retry.Backoff != "" {
+				if _, err := time.ParseDuration(s.Retry.Backoff); err != nil {
+					return fmt.Errorf("retry.backoff: %w", err)
+				}
+			}
+		}
+	case KindCondition:
+		if _, err := parseCondition(s.Condition); err != nil {
+			return err
+		}
+	case KindHumanGate:
+		if s.Task == nil || s.Task.Type == "" {
+			return fmt.Errorf("task.type is required")
+		}
+	case KindRefund:
+		if s.Refund != nil && s.Refund.SecondApprovalAboveCents < 0 {
+			return fmt.Errorf("refund.secondApprovalAboveCents must be >= 0")
+		}
+		if s.Refund != nil && s.Refund.Basis != "" && s.Refund.Basis != RefundBasisSeats {
+			return fmt.Errorf("refund.basis must be empty or %q", RefundBasisSeats)
+		}
+	case KindParallel:
+		if len(s.Branches) < 2 {
+			return fmt.Errorf("parallel step needs at least 2 branches")
+		}
+		seen := make(map[string]bool, len(s.Branches))
+		for _, b := range s.Branches {
+			if seen[b] {
+				return fmt.Errorf("duplicate branch %q", b)
+			}
+			seen[b] = true
+		}
+	case KindChildWorkflow:
+		required, ok := knownChildWorkflows[s.Workflow]
+		if !ok {
+			return fmt.Errorf("unknown child workflow %q", s.Workflow)
+		}
+		for _, p := range required {
+			if s.Params[p] == "" {
+				return fmt.Errorf("child workflow %s requires params.%s", s.Workflow, p)
+			}
+		}
+		for _, p := range []string{"searchEvery", "searchFor"} {
+			if v, ok := s.Params[p]; ok {
+				if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+					return fmt.Errorf("params.%s must be a positive duration", p)
+				}
+			}
+		}
+		for _, p := range []string{"priceBandPercent", "approvalAbovePercent"} {
+			if v, ok := s.Params[p]; ok {
+				if n, err := strconv.Atoi(v); err != nil || n < 0 {
+					return fmt.Errorf("params.%s must be a non-negative integer", p)
+				}
+			}
+		}
+	case KindWait:
+		if !slices.Contains(waitFields, s.Until) {
+			return fmt.Errorf("until must be one of %v", waitFields)
+		}
+	case KindFinish:
+		if s.Result == "" {
+			return fmt.Errorf("result is required")
+		}
+		if _, ok := modal.ParseOutcome(s.Result); !ok {
+			return fmt.Errorf("result %q is not a known outcome", s.Result)
+		}
+	default:
+		return fmt.Errorf("unknown kind %q", s.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package playbook
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"broken-order-service/internal/modal"
+)
+
+// finishSteps is appended to test playbooks that need somewhere to end.
+const finishSteps = `
+  - id: done
+    kind: finish
+    result: RESOLVED_AUTOMATICALLY
+`
+
+func TestDefaultPlaybooksCoverEveryIssueType(t *testing.T) {
+	all, err := NewStore("").All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, it := range modal.IssueTypes {
+		if _, ok := all[it]; !ok {
+			t.Errorf("no default playbook for %s", it)
+		}
+	}
+}
+
+func TestParseRejectsInvalidPlaybooks(t *testing.T) {
+	cases := []struct {
+		name  string
+		steps string
+		want  string
+	}{
+		{
+			name:  "unknown step kind",
+			steps: "  - id: a\n    kind: teleport\n" + finishSteps,
+			want:  `unknown kind "teleport"`,
+		},
+		{
+			name:  "unknown action",
+			steps: "  - id: a\n    kind: action\n    action: LaunchRocket\n" + finishSteps,
+			want:  `unknown action "LaunchRocket"`,
+		},
+		{
+			name:  "missing action param",
+			steps: "  - id: a\n    kind: action\n    action: NotifyBuyer\n" + finishSteps,
+			want:  "requires params.template",
+		},
+		{
+			name:  "duplicate step id",
+			steps: "  - id: done\n    kind: condition\n    condition: a == b\n" + finishSteps,
+			want:  `duplicate step id "done"`,
+		},
+		{
+			name:  "transition to unknown step",
+			steps: "  - id: a\n    kind: action\n    action: RetryTransfer\n    on:\n      ACCEPTED: nowhere\n" + finishSteps,
+			want:  `points to unknown step "nowhere"`,
+		},
+		{
+			name:  "unknown branch",
+			steps: "  - id: a\n    kind: parallel\n    branches: [b, c]\n" + finishSteps,
+			want:  `unknown branch "b"`,
+		},
+		{
+			name:  "bad condition",
+			steps: "  - id: a\n    kind: condition\n    condition: transferStatus ACCEPTED\n" + finishSteps,
+			want:  "expected",
+		},
+		{
+			name:  "no finish step",
+			steps: "  - id: a\n    kind: action\n    action: RetryTransfer\n",
+			want:  `step "a" can fall through past the last step`,
+		},
+		{
+			name: "unreachable finish",
+			steps: `
+  - id: a
+    kind: action
+    action: RetryTransfer
+    on:
+      "*": b
+  - id: b
+    kind: action
+    action: PingSupplier
+    params: {message: hi}
+    on:
+      "*": a
+` + finishSteps,
+			want: `step "a" cannot reach a finish step`,
+		},
+		{
+			name: "condition falling off the end",
+			steps: finishSteps + `
+  - id: a
+    kind: condition
+    condition: transferStatus == ACCEPTED
+    on:
+      "true": done
+`,
+			want: "", // "a" is unreachable, so its missing "false" transition is harmless
+		},
+		{
+			name:  "bad refund basis",
+			steps: "  - id: a\n    kind: refund\n    refund:\n      basis: vibes\n    on:\n      \"*\": done\n" + finishSteps,
+			want:  "refund.basis",
+		},
+		{
+			name:  "bad wait field",
+			steps: "  - id: a\n    kind: wait\n    until: eventDate\n" + finishSteps,
+			want:  "until must be one of",
+		},
+		{
+			name:  "unknown finish result",
+			steps: "  - id: a\n    kind: finish\n    result: MAYBE\n",
+			want:  `result "MAYBE" is not a known outcome`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte("issueType: TRANSFER_FAILED\nversion: 1\nsteps:\n"+tc.steps), ".yaml")
+			switch {
+			case tc.want == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.want != "" && err == nil:
+				t.Fatalf("expected an error containing %q", tc.want)
+			case tc.want != "" && !strings.Contains(err.Error(), tc.want):
+				t.Fatalf("error %q does not contain %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseRequiresIssueTypeAndSteps(t *testing.T) {
+	if _, err := Parse([]byte("version: 1\nsteps:\n"+finishSteps), ".yaml"); err == nil || !strings.Contains(err.Error(), "issueType is required") {
+		t.Errorf("missing issueType: got %v", err)
+	}
+	if _, err := Parse([]byte(`{"issueType": "TRANSFER_FAILED", "steps": []}`), ".json"); err == nil || !strings.Contains(err.Error(), "at least one step") {
+		t.Errorf("no steps: got %v", err)
+	}
+}
+
+// A retry loop is fine as long as one of its outcomes leads to a finish step.
+func TestParseAcceptsLoopWithExit(t *testing.T) {
+	_, err := Parse([]byte(`issueType: TRANSFER_FAILED
+steps:
+  - id: retry
+    kind: action
+    action: RetryTransfer
+    on:
+      ACCEPTED: done
+  - id: review
+    kind: human_gate
+    task: {type: RETRY_TRANSFER}
+    on:
+      approved: retry
+      rejected: done
+`+finishSteps), ".yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadRejectsDuplicateIssueType(t *testing.T) {
+	pb := "issueType: TRANSFER_FAILED\nsteps:\n" + finishSteps
+	fsys := fstest.MapFS{
+		"a.yaml": {Data: []byte(pb)},
+		"b.yaml": {Data: []byte(pb)},
+	}
+	err := loadFS(fsys, ".", make(map[modal.IssueType]Playbook))
+	if err == nil || !strings.Contains(err.Error(), "already declared by a.yaml") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestStoreDirOverridesDefaults(t *testing.T) {
+	dir := t.TempDir()
+	pb := "issueType: TRANSFER_FAILED\nversion: 99\nsteps:\n" + finishSteps
+	if err := os.WriteFile(filepath.Join(dir, "transfer.yaml"), []byte(pb), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, ok, err := NewStore(dir).Get(modal.IssueTransferFailed)
+	if err != nil || !ok {
+		t.Fatalf("Get: ok=%v err=%v", ok, err)
+	}
+	if p.Version != 99 {
+		t.Errorf("version = %d, want the override's 99", p.Version)
+	}
+}
+
+func TestEvalCondition(t *testing.T) {
+	fields := map[string]string{"transferStatus": "ACCEPTED", "decidedBy": ""}
+	lookup := func(f string) (string, bool) {
+		v, ok := fields[f]
+		return v, ok
+	}
+	cases := []struct {
+		expr    string
+		want    bool
+		wantErr string
+	}{
+		{expr: "transferStatus == ACCEPTED", want: true},
+		{expr: "transferStatus==ACCEPTED", want: true},
+		{expr: "transferStatus == NOT_ACCEPTED", want: false},
+		{expr: "transferStatus != NOT_ACCEPTED", want: true},
+		{expr: `decidedBy != ""`, want: false},
+		{expr: `decidedBy == ""`, want: true},
+		{expr: "paymentStatus == AUTHORIZED", wantErr: `unknown field "paymentStatus"`},
+		{expr: "== ACCEPTED", wantErr: "missing field"},
+		{expr: "transferStatus > 1", wantErr: "expected"},
+	}
+	for _, tc := range cases {
+		got, err := EvalCondition(tc.expr, lookup)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("%s: error %v, want one containing %q", tc.expr, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("%s = %v, %v; want %v", tc.expr, got, err, tc.want)
+		}
+	}
+}
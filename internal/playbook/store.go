@@ -0,0 +1,103 @@
+package playbook
+
+import (
+	"broken-order-service/internal/modal"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed defaults/*.yaml
+var defaultsFS embed.FS
+
+// Store resolves playbooks by issue type.
+// Built-in defaults are embedded in the binary; playbooks found in Dir (if set) override them per issue type.
+// Dir is re-read on every lookup so ops can add or tweak playbooks without redeploying the worker.
+// Workflows only see a playbook through the LoadPlaybook activity, so a running workflow keeps
+// the version it loaded even if the file changes underneath it.
+type Store struct {
+	Dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// Get returns the playbook for issueType. ok is false if none is configured.
+func (s *Store) Get(issueType modal.IssueType) (Playbook, bool, error) {
+	all, err := s.All()
+	if err != nil {
+		return Playbook{}, false, err
+	}
+	p, ok := all[issueType]
+	return p, ok, nil
+}
+
+// All loads every playbook, defaults first and then Dir overrides.
+func (s *Store) All() (map[modal.IssueType]Playbook, error) {
+	out := make(map[modal.IssueType]Playbook)
+	if err := loadFS(defaultsFS, "defaults", out); err != nil {
+		return nil, fmt.Errorf("load default playbooks: %w", err)
+	}
+	if s.Dir != "" {
+		if err := loadFS(os.DirFS(s.Dir), ".", out); err != nil {
+			return nil, fmt.Errorf("load playbooks from %s: %w", s.Dir, err)
+		}
+	}
+	return out, nil
+}
+
+// loadFS adds every playbook in dir to out, replacing playbooks loaded from elsewhere. Two files in dir for the
+// same issue type are an error rather than one silently winning.
+func loadFS(fsys fs.FS, dir string, out map[modal.IssueType]Playbook) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	loaded := make(map[modal.IssueType]string, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := path.Join(dir, e.Name())
+		ext := strings.ToLower(path.Ext(name))
+		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+			continue
+		}
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		p, err := Parse(b, ext)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if other, ok := loaded[p.IssueType]; ok {
+			return fmt.Errorf("%s: issue type %s is already declared by %s", name, p.IssueType, other)
+		}
+		loaded[p.IssueType] = name
+		out[p.IssueType] = p
+	}
+	return nil
+}
+
+// Parse decodes and validates a playbook. ext selects the format (".json", otherwise YAML).
+func Parse(b []byte, ext string) (Playbook, error) {
+	var p Playbook
+	var err error
+	if ext == ".json" {
+		err = json.Unmarshal(b, &p)
+	} else {
+		err = yaml.Unmarshal(b, &p)
+	}
+	if err != nil {
+		return Playbook{}, err
+	}
+	return p, p.Validate()
+}
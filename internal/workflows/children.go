@@ -0,0 +1,304 @@
+package workflows
+
+import (
+	"broken-order-service/internal/modal"
+	"broken-order-service/internal/playbook"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Child workflows run sub-remediations that are independent of the parent's own steps and may take long, such as
+// sourcing replacement seats while the parent waits on the buyer. Each runs as its own execution with its own audit
+// log and ledger, and answers the same queries as ResolveBrokenOrder, so the UI detail page works for it too. The
+// parent tracks its status in the case file and adds its ledger to its own when it completes.
+
+// childWorkflowID is <workflowId>-<stepId>; a step re-entered through a playbook cycle gets a visit suffix.
+func childWorkflowID(workflowID, stepID string, visit int) string {
+	if visit > 1 {
+		return fmt.Sprintf("%s-%s-%d", workflowID, stepID, visit)
+	}
+	return workflowID + "-" + stepID
+}
+
+// runChild starts the step's child workflow and waits for it; the step's outcome is the child's.
+func (r *runner) runChild(ctx workflow.Context, step playbook.Step) (string, error) {
+	stageChild.mark(ctx)
+	id := childWorkflowID(r.workflowID, step.ID, r.visits[step.ID])
+	cctx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID: id,
+		// A parent that is cancelled or terminated cancels its children, which release what they hold.
+		ParentClosePolicy:     enums.PARENT_CLOSE_POLICY_REQUEST_CANCEL,
+		WaitForCancellation:   true,
+		TypedSearchAttributes: temporal.NewSearchAttributes(OrderIDKey.ValueSet(r.orderID)),
+	})
+	req := modal.ChildRequest{
+		ParentWorkflowID: r.workflowID,
+		StepID:           step.ID,
+		Order:            r.state.CaseFile.Order,
+		Params:           step.Params,
+	}
+
+	// Children of parallel branches are tracked concurrently, so the entry is addressed by index.
+	k := len(r.state.CaseFile.Children)
+	r.state.CaseFile.Children = append(r.state.CaseFile.Children, modal.ChildWorkflow{
+		WorkflowID: id,
+		Type:       step.Workflow,
+		StepID:     step.ID,
+		Status:     modal.ChildRunning,
+		StartedAt:  workflow.Now(ctx),
+	})
+
+	fut := workflow.ExecuteChildWorkflow(cctx, step.Workflow, req)
+	var exec workflow.Execution
+	if err := fut.GetChildWorkflowExecution().Get(ctx, &exec); err != nil {
+		r.closeChild(ctx, k, "", err)
+		return "", err
+	}
+	r.state.CaseFile.Children[k].RunID = exec.RunID
+	r.audit("CHILD_STARTED", "child workflow started", map[string]any{
+		"step":       step.ID,
+		"type":       step.Workflow,
+		"workflowId": id,
+	})
+
+	var res modal.ChildResult
+	if err := fut.Get(ctx, &res); err != nil {
+		r.closeChild(ctx, k, "", err)
+		return "", err
+	}
+	r.state.Attempts = append(r.state.Attempts, res.Actions...)
+	if res.Reservation != nil {
+		r.state.CaseFile.Replacement = res.Reservation
+		// The child handed the seats over; release them if the parent fails later.
+		if i := reservedBy(res.Actions, res.Reservation); i >= 0 {
+			r.saga.add(releaseSeats(res.Actions[i], *res.Reservation))
+		}
+	}
+	r.closeChild(ctx, k, res.Outcome, nil)
+	return res.Outcome, nil
+}
+
+// closeChild records how the child at index k of the case file ended.
+func (r *runner) closeChild(ctx workflow.Context, k int, outcome string, err error) {
+	c := &r.state.CaseFile.Children[k]
+	c.ClosedAt = workflow.Now(ctx)
+	switch {
+	case err == nil:
+		c.Status, c.Outcome = modal.ChildCompleted, outcome
+		r.audit("CHILD_COMPLETED", "child workflow completed", map[string]any{
+			"workflowId": c.WorkflowID,
+			"outcome":    outcome,
+		})
+		return
+	case temporal.IsCanceledError(err):
+		c.Status = modal.ChildCancelled
+	default:
+		c.Status = modal.ChildFailed
+	}
+	c.Error = err.Error()
+	r.audit("CHILD_FAILED", "child workflow did not complete", map[string]any{
+		"workflowId": c.WorkflowID,
+		"status":     c.Status,
+		"error":      c.Error,
+	})
+}
+
+// reservedBy returns the index of the ledger entry that made res, or -1. A SourceReplacement child stops at
+// its first successful reservation, so that is the last one in its ledger.
+func reservedBy(attempts []modal.ActionAttemp, res *modal.Reservation) int {
+	for i := len(attempts) - 1; i >= 0; i-- {
+		if attempts[i].ActionType == "RESERVE_SEATS" && attempts[i].Result == res.Status {
+			return i
+		}
+	}
+	return -1
+}
+
+// newChildRunner sets up the state, audit log and queries of a child workflow.
+func newChildRunner(ctx workflow.Context, req modal.ChildRequest) *runner {
+	state := newWorkflowState()
+	state.CaseFile = modal.CaseFile{
+		OrderID:          req.Order.OrderID,
+		Order:            req.Order,
+		GeneratedAt:      workflow.Now(ctx),
+		ParentWorkflowID: req.ParentWorkflowID,
+	}
+	state.registerQueries(ctx)
+	return &runner{
+		workflowID: workflow.GetInfo(ctx).WorkflowExecution.ID,
+		orderID:    req.Order.OrderID,
+		state:      state,
+		audit:      state.auditFunc(ctx),
+		visits:     make(map[string]int),
+	}
+}
+
+// durationParam returns the duration in params[name], or def when unset. Playbook validation checks the format.
+func durationParam(params map[string]string, name string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(params[name]); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
+
+// intParam returns the integer in params[name], or def when unset. Playbook validation checks the format.
+func intParam(params map[string]string, name string, def int) int {
+	if n, err := strconv.Atoi(params[name]); err == nil && n >= 0 {
+		return n
+	}
+	return def
+}
+
+// priceBand is the band within percent of paidCents. An order without a known price is searched without one.
+func priceBand(paidCents int64, percent int) modal.PriceBand {
+	if paidCents <= 0 {
+		return modal.PriceBand{}
+	}
+	return modal.PriceBand{
+		MinCents: max(paidCents*int64(100-percent)/100, 0),
+		MaxCents: paidCents * int64(100+percent) / 100,
+	}
+}
+
+// SourceReplacement finds comparable seats within the price band for the order and reserves the cheapest listing
+// it can get. While nothing is available it searches again every searchEvery, for up to searchFor. A reservation
+// costing more than approvalAbovePercent over what the buyer paid ends with "needs_approval" rather than
+// "reserved". If it fails or is cancelled after reserving, the seats are released; on success they are handed to
+// the parent.
+func SourceReplacement(ctx workflow.Context, req modal.ChildRequest) (modal.ChildResult, error) {
+	r := newChildRunner(ctx, req)
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+	r.audit("STARTED", "sourcing replacement seats", map[string]any{"parentWorkflowId": req.ParentWorkflowID})
+
+	res, err := r.sourceReplacement(ctx, req.Params)
+	if err != nil {
+		dctx, _ := workflow.NewDisconnectedContext(ctx)
+		r.compensate(dctx, err)
+		return modal.ChildResult{}, err
+	}
+	r.audit("DONE", res.Message, map[string]any{"outcome": res.Outcome})
+	return res, nil
+}
+
+func (r *runner) sourceReplacement(ctx workflow.Context, params map[string]string) (modal.ChildResult, error) {
+	every := durationParam(params, "searchEvery", time.Hour)
+	deadline := workflow.Now(ctx).Add(durationParam(params, "searchFor", 24*time.Hour))
+	paid := r.state.CaseFile.Order.AmountCents
+	band := priceBand(paid, intParam(params, "priceBandPercent", 25))
+	approvalAbove := intParam(params, "approvalAbovePercent", 10)
+
+	attempt := 0
+	for search := 1; ; search++ {
+		var listings []modal.SeatListing
+		if err := workflow.ExecuteActivity(ctx, "FindReplacementSeats", r.orderID, band).Get(ctx, &listings); err != nil {
+			r.audit("ERROR", "FindReplacementSeats failed", map[string]any{"error": err.Error()})
+			return modal.ChildResult{}, err
+		}
+		r.audit("SEATS_SEARCHED", "searched for comparable seats", map[string]any{
+			"search":   search,
+			"listings": len(listings),
+		})
+		// Cheapest first; a listing someone else reserved in the meantime is skipped.
+		for _, l := range listings {
+			attempt++
+			res, err := r.reserveSeats(ctx, l, attempt)
+			if err != nil {
+				continue
+			}
+			outcome := playbook.OutcomeReserved
+			if delta := l.PriceCents - paid; paid > 0 && delta*100 > paid*int64(approvalAbove) {
+				outcome = playbook.OutcomeNeedsApproval
+			}
+			return modal.ChildResult{
+				Outcome: outcome,
+				Message: fmt.Sprintf("reserved listing %s for %s (paid %s)", l.ListingID,
+					modal.FormatAmount(l.PriceCents, l.Currency), modal.FormatAmount(paid, r.state.CaseFile.Order.Currency)),
+				Reservation: &res,
+				Actions:     slices.Clone(r.state.Attempts),
+			}, nil
+		}
+		if !workflow.Now(ctx).Add(every).Before(deadline) {
+			break
+		}
+		if err := workflow.Sleep(ctx, every); err != nil {
+			return modal.ChildResult{}, err
+		}
+	}
+	return modal.ChildResult{
+		Outcome: playbook.OutcomeNotFound,
+		Message: "no comparable seats could be reserved",
+		Actions: slices.Clone(r.state.Attempts),
+	}, nil
+}
+
+// reserveSeats reserves listing l as the given attempt and registers its release with the saga.
+func (r *runner) reserveSeats(ctx workflow.Context, l modal.SeatListing, attempt int) (modal.Reservation, error) {
+	i, req := r.beginAttempt(ctx, "RESERVE_SEATS", playbook.Step{ID: "reserve-seats"}, attempt)
+	var res modal.Reservation
+	if err := workflow.ExecuteActivity(ctx, "ReserveSeats", req, l).Get(ctx, &res); err != nil {
+		r.endAttempt(i, modal.AttemptFailed)
+		r.audit("ERROR", "ReserveSeats failed", map[string]any{
+			"listingId":      l.ListingID,
+			"idempotencyKey": req.IdempotencyKey,
+			"error":          err.Error(),
+		})
+		return res, err
+	}
+	r.endAttempt(i, res.Status)
+	r.saga.add(releaseSeats(r.state.Attempts[i], res))
+
+	r.audit("SEATS_RESERVED", "replacement seats reserved", map[string]any{
+		"reservationId":   res.ReservationID,
+		"listingId":       l.ListingID,
+		"supplierId":      l.SupplierID,
+		"priceCents":      l.PriceCents,
+		"priceDeltaCents": l.PriceCents - r.state.CaseFile.Order.AmountCents,
+		"currency":        l.Currency,
+		"idempotencyKey":  req.IdempotencyKey,
+	})
+	return res, nil
+}
+
+// releaseSeats is the compensation for the reservation res made by the ledger entry forward.
+func releaseSeats(forward modal.ActionAttemp, res modal.Reservation) compensation {
+	return compensation{
+		actionType: "RELEASE_SEATS",
+		activity:   "ReleaseSeats",
+		args:       []any{res},
+		result:     modal.ReservationReleased,
+		forward:    forward,
+	}
+}
+
+// buyerCommsActivityOptions retry a notification for up to a day: the parent carries on meanwhile, and a provider
+// outage should delay the message rather than fail the order.
+var buyerCommsActivityOptions = workflow.ActivityOptions{
+	StartToCloseTimeout:    10 * time.Second,
+	ScheduleToCloseTimeout: 24 * time.Hour,
+	RetryPolicy: &temporal.RetryPolicy{
+		InitialInterval:    1 * time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    10 * time.Minute,
+	},
+}
+
+// NotifyBuyer sends the buyer the notification in params.template, like the NotifyBuyer action but retried
+// for longer.
+func NotifyBuyer(ctx workflow.Context, req modal.ChildRequest) (modal.ChildResult, error) {
+	r := newChildRunner(ctx, req)
+	ctx = workflow.WithActivityOptions(ctx, buyerCommsActivityOptions)
+	r.audit("STARTED", "notifying buyer", map[string]any{"parentWorkflowId": req.ParentWorkflowID})
+
+	outcome, err := actionNotifyBuyer(ctx, r, playbook.Step{ID: "notify-buyer", Params: req.Params}, 1)
+	if err != nil {
+		return modal.ChildResult{}, err
+	}
+	r.audit("DONE", "buyer notified", map[string]any{"outcome": outcome})
+	return modal.ChildResult{Outcome: outcome, Actions: slices.Clone(r.state.Attempts)}, nil
+}
@@ -0,0 +1,93 @@
+package workflows
+
+import (
+	"broken-order-service/internal/modal"
+	"broken-order-service/internal/playbook"
+	"fmt"
+	"strings"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// minConfidence is the classifier confidence below which an order is triaged by a human instead of
+// going straight to a playbook.
+const minConfidence = 0.7
+
+// triageStepID names the TRIAGE task, which is opened outside any playbook.
+const triageStepID = "triage"
+
+// triageSLA bounds the TRIAGE task. It opens before the issue type, and so the playbook and its SLAs, is known.
+// If nobody confirms the issue type in time the classifier's suggestion stands; the playbook's own human tasks
+// still guard refunds and purchases.
+var triageSLA = playbook.SLA{Target: "1h", HardDeadline: "24h", DefaultAction: playbook.OutcomeApproved}
+
+// classify runs the ClassifyIssue activity and sets the case file's issue type. A low-confidence verdict
+// (or a classifier that keeps failing) opens a TRIAGE task; ok is false when the triager rejected the
+// order, which then needs manual handling outside the playbooks.
+func (r *runner) classify(ctx workflow.Context) (ok bool, err error) {
+	stageClassify.mark(ctx)
+	cf := &r.state.CaseFile
+	var c modal.Classification
+	if err := workflow.ExecuteActivity(ctx, "ClassifyIssue", *cf).Get(ctx, &c); err != nil {
+		// The order is still actionable; a human decides the issue type instead.
+		c = modal.Classification{
+			IssueType:  cf.Event.ReportedIssueType,
+			Tier:       modal.Tier1,
+			Reasons:    []string{"classifier failed: " + err.Error()},
+			Classifier: "none",
+		}
+		if !c.IssueType.Known() {
+			c.IssueType = modal.IssueTransferFailed
+		}
+	}
+	cf.Classification = c
+	cf.IssueType = c.IssueType
+	r.audit("ISSUE_CLASSIFIED", "issue classified from case file signals", map[string]any{
+		"issueType":  c.IssueType,
+		"tier":       c.Tier,
+		"confidence": c.Confidence,
+		"reasons":    c.Reasons,
+		"classifier": c.Classifier,
+	})
+	r.upsertSearchAttributes(ctx)
+
+	if c.Confidence >= minConfidence {
+		return true, nil
+	}
+	return r.triage(ctx, c)
+}
+
+// triage opens a TRIAGE task carrying the classifier's suggestion. Approving it confirms the suggested
+// issue type, or the one given in the decision; so does the SLA default when nobody decides.
+func (r *runner) triage(ctx workflow.Context, c modal.Classification) (bool, error) {
+	step := playbook.Step{ID: triageStepID}
+	r.visits[step.ID]++
+	if stageClassify.since(ctx, 2) {
+		r.slas = map[string]playbook.SLA{modal.TaskTriage: triageSLA}
+	}
+	task := r.newTask(ctx, step, playbook.TaskSpec{
+		Type:  modal.TaskTriage,
+		Title: "Confirm issue type",
+		Reason: fmt.Sprintf("classifier is not confident (%.2f) that this is %s: %s",
+			c.Confidence, c.IssueType, strings.Join(c.Reasons, "; ")),
+	})
+	task.Classification = &c
+
+	outcome, err := r.awaitDecision(ctx, task)
+	if err != nil || outcome != playbook.OutcomeApproved {
+		return false, err
+	}
+
+	cf := &r.state.CaseFile
+	// An SLA default approves without a decision, leaving the suggested issue type.
+	if n := len(task.Approvals); n > 0 && task.Approvals[n-1].IssueType.Known() {
+		cf.IssueType = task.Approvals[n-1].IssueType
+	}
+	r.audit("ISSUE_TRIAGED", "issue type confirmed by triage", map[string]any{
+		"taskId":    task.ID,
+		"suggested": c.IssueType,
+		"issueType": cf.IssueType,
+	})
+	r.upsertSearchAttributes(ctx)
+	return true, nil
+}
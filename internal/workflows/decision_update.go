@@ -0,0 +1,108 @@
+package workflows
+
+import (
+	"broken-order-service/internal/modal"
+	"fmt"
+	"slices"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Error types returned by the TaskDecisionUpdate validator. Callers map them to responses
+// (see cmd/api): invalid and unknown decisions are unprocessable, stale ones conflict.
+const (
+	ErrTypeInvalidDecision   = "InvalidDecision"
+	ErrTypeUnknownTask       = "UnknownTask"
+	ErrTypeTaskClosed        = "TaskClosed"
+	ErrTypeDuplicateApproval = "DuplicateApproval"
+)
+
+// registerDecisionUpdate registers the TaskDecisionUpdate handler. The validator rejects decisions
+// for unknown or already-decided tasks before anything is written to history; accepted decisions go
+// through the same per-task inbox as signals, and the update returns once the task has processed it.
+func (r *runner) registerDecisionUpdate(ctx workflow.Context) error {
+	return workflow.SetUpdateHandlerWithOptions(ctx, TaskDecisionUpdate,
+		func(ctx workflow.Context, d modal.TaskDecision) (modal.DecisionResult, error) {
+			task := r.state.Tasks[d.TaskID]
+			// The task may have closed since the validator ran (an SLA default, or a parallel branch ending), and
+			// a send on its missing inbox would block forever.
+			inbox, ok := r.inbox[d.TaskID]
+			if !ok || !task.Status.Active() {
+				return modal.DecisionResult{}, temporal.NewApplicationError(
+					fmt.Sprintf("task %q is already %s", d.TaskID, task.Status), ErrTypeTaskClosed)
+			}
+			if d.Approved && approvedBy(task, d.Decider) {
+				return modal.DecisionResult{}, duplicateApproval(d)
+			}
+			inbox.Send(ctx, d)
+			// The task has processed the decision once it closed or counted an approval from this decider. That
+			// approval may be from another request by the same decider that got there first, in which case the task
+			// discarded this one as a duplicate.
+			if err := workflow.Await(ctx, func() bool {
+				return !task.Status.Active() || (d.Approved && approvedBy(task, d.Decider))
+			}); err != nil {
+				return modal.DecisionResult{}, err
+			}
+			switch {
+			case decisionApplied(task, d):
+				return r.decisionResult(task), nil
+			case d.Approved && approvedBy(task, d.Decider):
+				return modal.DecisionResult{}, duplicateApproval(d)
+			default:
+				return modal.DecisionResult{}, temporal.NewApplicationError(
+					fmt.Sprintf("task %q was %s before the decision was applied", d.TaskID, task.Status), ErrTypeTaskClosed)
+			}
+		},
+		workflow.UpdateHandlerOptions{Validator: r.validateDecision},
+	)
+}
+
+// validateDecision must not change workflow state; it runs before the update is accepted.
+func (r *runner) validateDecision(d modal.TaskDecision) error {
+	if d.TaskID == "" {
+		return temporal.NewApplicationError("taskId is required", ErrTypeInvalidDecision)
+	}
+	task, ok := r.state.Tasks[d.TaskID]
+	if !ok {
+		return temporal.NewApplicationError(fmt.Sprintf("unknown task %q", d.TaskID), ErrTypeUnknownTask)
+	}
+	if _, open := r.inbox[d.TaskID]; !open || !task.Status.Active() {
+		return temporal.NewApplicationError(fmt.Sprintf("task %q is already %s", d.TaskID, task.Status), ErrTypeTaskClosed)
+	}
+	if d.IssueType != "" && (task.Type != modal.TaskTriage || !d.IssueType.Known()) {
+		return temporal.NewApplicationError(fmt.Sprintf("issueType %q cannot be set on %s task %q", d.IssueType, task.Type, d.TaskID), ErrTypeInvalidDecision)
+	}
+	if d.Approved && approvedBy(task, d.Decider) {
+		return duplicateApproval(d)
+	}
+	return nil
+}
+
+func duplicateApproval(d modal.TaskDecision) error {
+	return temporal.NewApplicationError(fmt.Sprintf("%s already approved task %q", d.Decider, d.TaskID), ErrTypeDuplicateApproval)
+}
+
+// decisionApplied reports whether task acted on d: counted it as an approval, or was rejected by it.
+func decisionApplied(task *modal.HumanTask, d modal.TaskDecision) bool {
+	if !d.Approved {
+		return task.Status == modal.TaskRejected && task.DecidedBy == d.Decider
+	}
+	return slices.Contains(task.Approvals, d)
+}
+
+func (r *runner) decisionResult(task *modal.HumanTask) modal.DecisionResult {
+	res := modal.DecisionResult{
+		TaskID:            task.ID,
+		TaskStatus:        task.Status,
+		Approvals:         len(task.Approvals),
+		RequiredApprovals: task.RequiredApprovals,
+		WorkflowStatus:    r.status,
+	}
+	for _, t := range r.state.taskList() {
+		if t.Status.Active() {
+			res.OpenTasks = append(res.OpenTasks, t.ID)
+		}
+	}
+	return res
+}
@@ -0,0 +1,127 @@
+package workflows
+
+import (
+	"broken-order-service/internal/modal"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// gatherOptions are tighter than the default activity options: a context source that is slow or down
+// should be marked unavailable quickly instead of holding up the whole case file.
+var gatherOptions = workflow.ActivityOptions{
+	StartToCloseTimeout: 5 * time.Second,
+	RetryPolicy: &temporal.RetryPolicy{
+		InitialInterval:    500 * time.Millisecond,
+		BackoffCoefficient: 2.0,
+		MaximumAttempts:    2,
+	},
+}
+
+// contextSource is one per-source activity feeding a section of the case file.
+type contextSource struct {
+	section  string
+	activity string
+	// result returns a pointer for the activity result; apply copies it into the case file.
+	result func() any
+	apply  func(cf *modal.CaseFile)
+}
+
+// gatherCaseFile fetches every context source concurrently and merges the results. The issue type is
+// left for the classifier (see classify.go).
+// A failing source marks its section unavailable instead of failing the workflow; each source's
+// outcome and latency is recorded in the audit log.
+func gatherCaseFile(ctx workflow.Context, ev modal.BrokenOrderEvent, audit func(kind, message string, data map[string]any)) modal.CaseFile {
+	var (
+		order    modal.OrderDetails
+		transfer modal.TransferContext
+		comms    []modal.SupplierMessage
+		payment  modal.PaymentContext
+	)
+	sources := []contextSource{
+		{
+			section:  modal.SectionOrder,
+			activity: "FetchOrder",
+			result:   func() any { return &order },
+			apply: func(cf *modal.CaseFile) {
+				cf.Order = order
+				cf.BuyerEmail = order.BuyerEmail
+				cf.ExpectedSeats = order.Seats
+			},
+		},
+		{
+			section:  modal.SectionTransfer,
+			activity: "FetchTransfer",
+			result:   func() any { return &transfer },
+			apply: func(cf *modal.CaseFile) {
+				cf.TransferStatus = transfer.Status
+				cf.TransferAttempts = transfer.Attempts
+				cf.DeliveredSeats = transfer.DeliveredSeats
+				cf.TransferBlockedUntil = transfer.BlockedUntil
+			},
+		},
+		{
+			section:  modal.SectionSupplierComms,
+			activity: "FetchSupplierComms",
+			result:   func() any { return &comms },
+			apply:    func(cf *modal.CaseFile) { cf.SupplierComms = comms },
+		},
+		{
+			section:  modal.SectionPayment,
+			activity: "FetchPayment",
+			result:   func() any { return &payment },
+			apply: func(cf *modal.CaseFile) {
+				cf.PaymentStatus = payment.Authorization.Status
+				cf.RefundEligibility = payment.RefundEligibility
+			},
+		},
+	}
+
+	type sourceResult struct {
+		err     error
+		latency time.Duration
+	}
+	results := make([]sourceResult, len(sources))
+
+	actx := workflow.WithActivityOptions(ctx, gatherOptions)
+	started := workflow.Now(ctx)
+	wg := workflow.NewWaitGroup(ctx)
+	for i, src := range sources {
+		// Schedule every activity before waiting on any of them so they run concurrently.
+		f := workflow.ExecuteActivity(actx, src.activity, ev.OrderID)
+		wg.Add(1)
+		workflow.Go(ctx, func(gctx workflow.Context) {
+			defer wg.Done()
+			results[i].err = f.Get(gctx, src.result())
+			results[i].latency = workflow.Now(gctx).Sub(started)
+		})
+	}
+	wg.Wait(ctx)
+
+	cf := modal.CaseFile{
+		OrderID:        ev.OrderID,
+		TransferStatus: modal.TransferNotAccepted,
+		GeneratedAt:    workflow.Now(ctx),
+		Event:          ev,
+		Sections:       make(map[string]modal.SectionStatus, len(sources)),
+	}
+	// Merge and audit in a fixed order so history and audit log are stable across replays.
+	for i, src := range sources {
+		data := map[string]any{
+			"section":   src.section,
+			"latencyMs": results[i].latency.Milliseconds(),
+		}
+		if err := results[i].err; err != nil {
+			cf.Sections[src.section] = modal.SectionUnavailable
+			data["error"] = err.Error()
+			audit("CONTEXT_UNAVAILABLE", "context source failed; section marked unavailable", data)
+			continue
+		}
+		src.apply(&cf)
+		cf.Sections[src.section] = modal.SectionAvailable
+		audit("CONTEXT_FETCHED", "context source fetched", data)
+	}
+	cf.BuildTimeline()
+	return cf
+}
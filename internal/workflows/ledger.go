@@ -0,0 +1,67 @@
+package workflows
+
+import (
+	"broken-order-service/internal/modal"
+	"broken-order-service/internal/playbook"
+	"fmt"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// idempotencyKey derives the key for one attempt of a step: workflow ID + step + attempt.
+// It only depends on workflow history, so it is identical when Temporal retries the activity or
+// replays the workflow. A step re-entered through a playbook cycle gets a visit suffix (step#2)
+// so the new run is not mistaken for a repeat of the old one.
+func idempotencyKey(workflowID, stepID string, visit, attempt int) string {
+	if visit > 1 {
+		stepID = fmt.Sprintf("%s#%d", stepID, visit)
+	}
+	return fmt.Sprintf("%s/%s/%d", workflowID, stepID, attempt)
+}
+
+// beginAttempt appends a PENDING entry to the attempt ledger before a side effect is executed and
+// returns its index (for endAttempt) together with the entry to pass to the activity.
+func (r *runner) beginAttempt(ctx workflow.Context, actionType string, step playbook.Step, attempt int) (int, modal.ActionAttemp) {
+	key := idempotencyKey(r.workflowID, step.ID, r.visits[step.ID], attempt)
+	a := modal.ActionAttemp{
+		AttemptID:      key,
+		OrderID:        r.orderID,
+		ActionType:     actionType,
+		IdempotencyKey: key,
+		AttemptedAt:    workflow.Now(ctx),
+		Result:         modal.AttemptPending,
+		StepID:         step.ID,
+		Attempt:        attempt,
+	}
+	r.state.Attempts = append(r.state.Attempts, a)
+	return len(r.state.Attempts) - 1, a
+}
+
+// beginCompensation appends a PENDING entry for the compensation c, keyed after the side effect it undoes
+// (<forward key>/undo) so a replayed or retried compensation is recognised by the adapter as well.
+func (r *runner) beginCompensation(ctx workflow.Context, c compensation) (int, modal.ActionAttemp) {
+	key := c.forward.IdempotencyKey + "/undo"
+	a := modal.ActionAttemp{
+		AttemptID:      key,
+		OrderID:        r.orderID,
+		ActionType:     c.actionType,
+		IdempotencyKey: key,
+		AttemptedAt:    workflow.Now(ctx),
+		Result:         modal.AttemptPending,
+		StepID:         c.forward.StepID,
+		Attempt:        c.forward.Attempt,
+	}
+	r.state.Attempts = append(r.state.Attempts, a)
+	return len(r.state.Attempts) - 1, a
+}
+
+// endAttempt records the result of the ledger entry at i. Transfers (retries and replacements) are also added
+// to the case file so they show up alongside the attempts reported by the Transfer service.
+func (r *runner) endAttempt(i int, result string) {
+	a := &r.state.Attempts[i]
+	a.Result = result
+	if a.ActionType == "RETRY_TRANSFER" || a.ActionType == "TRANSFER_REPLACEMENT" {
+		r.state.CaseFile.TransferAttempts = append(r.state.CaseFile.TransferAttempts, *a)
+		r.state.CaseFile.BuildTimeline()
+	}
+}
@@ -0,0 +1,58 @@
+package workflows
+
+import (
+	"broken-order-service/internal/playbook"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// runParallel runs the branch steps of a parallel step concurrently and waits for all of them.
+// If a branch fails, the others are cancelled (their open tasks expire) and the first error is returned.
+// The first branch's outcome drives the transition; every branch outcome is audited.
+func (r *runner) runParallel(ctx workflow.Context, step playbook.Step) (string, error) {
+	stageParallel.mark(ctx)
+	bctx, cancel := workflow.WithCancel(ctx)
+	defer cancel()
+
+	outcomes := make([]string, len(step.Branches))
+	errs := make([]error, len(step.Branches))
+	wg := workflow.NewWaitGroup(ctx)
+	for i, id := range step.Branches {
+		branch := r.steps[id]
+		r.visits[id]++
+		wg.Add(1)
+		workflow.Go(bctx, func(gctx workflow.Context) {
+			defer wg.Done()
+			outcomes[i], errs[i] = r.runStep(gctx, branch)
+			if errs[i] != nil {
+				cancel()
+			}
+		})
+	}
+	wg.Wait(ctx)
+
+	results := make(map[string]any, len(step.Branches))
+	for i, id := range step.Branches {
+		if errs[i] != nil {
+			results[id] = "ERROR"
+			continue
+		}
+		results[id] = outcomes[i]
+	}
+	r.audit("PARALLEL_COMPLETED", "parallel branches completed", map[string]any{
+		"step":     step.ID,
+		"outcomes": results,
+	})
+	// Cancelled branches are a consequence of another branch failing; report the original failure first.
+	var firstErr error
+	for _, err := range errs {
+		if err != nil && (firstErr == nil || temporal.IsCanceledError(firstErr)) {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return outcomes[0], nil
+}
@@ -0,0 +1,411 @@
+package workflows
+
+import (
+	"broken-order-service/internal/modal"
+	"broken-order-service/internal/playbook"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// maxTransitions guards against cycles in misconfigured playbooks.
+const maxTransitions = 100
+
+const playbookErrorType = "PlaybookError"
+
+// actionFunc executes one attempt of a playbook action and returns its outcome.
+type actionFunc func(ctx workflow.Context, r *runner, step playbook.Step, attempt int) (string, error)
+
+// actions maps playbook action names to their workflow implementation.
+// New actions must also be added to the playbook package so playbooks referencing them validate.
+var actions = map[string]actionFunc{
+	playbook.ActionRetryTransfer:          actionRetryTransfer,
+	playbook.ActionReauthorizePayment:     actionReauthorizePayment,
+	playbook.ActionNotifyBuyer:            actionNotifyBuyer,
+	playbook.ActionNotifyExpectedDelivery: actionNotifyExpectedDelivery,
+	playbook.ActionPingSupplier:           actionPingSupplier,
+	playbook.ActionPurchaseReplacement:    actionPurchaseReplacement,
+	playbook.ActionTransferReplacement:    actionTransferReplacement,
+	playbook.ActionReleaseReplacement:     actionReleaseReplacement,
+	playbook.ActionCompareSeats:           actionCompareSeats,
+	playbook.ActionRequestSeatCorrection:  actionRequestSeatCorrection,
+}
+
+// runner interprets a playbook inside the workflow.
+// Everything it does goes through workflow APIs, so the same playbook replays deterministically.
+type runner struct {
+	workflowID string
+	orderID    string
+	state      *workflowState
+	audit      func(kind, message string, data map[string]any)
+	// visits counts how often each step has been entered; it keeps idempotency keys unique across cycles.
+	visits map[string]int
+	// steps indexes the playbook by step ID (for parallel branches).
+	steps map[string]playbook.Step
+	// inbox holds the decision channel of every open task (see tasks.go).
+	inbox map[string]workflow.Channel
+	// slas holds the playbook's SLA per task type (see sla.go).
+	slas map[string]playbook.SLA
+	// status is the ResolutionStatus search attribute; indexed is what was last upserted (see search_attributes.go).
+	status  string
+	indexed indexedState
+	// saga holds the compensations for the side effects executed so far (see saga.go).
+	saga saga
+	// decider is whoever made the last human decision on the playbook path taken so far.
+	decider string
+}
+
+func (r *runner) run(ctx workflow.Context, pb playbook.Playbook) (modal.Resolution, error) {
+	stageRunPlaybook.mark(ctx)
+	index := make(map[string]int, len(pb.Steps))
+	r.steps = make(map[string]playbook.Step, len(pb.Steps))
+	for i, s := range pb.Steps {
+		index[s.ID] = i
+		r.steps[s.ID] = s
+	}
+
+	if r.visits == nil {
+		r.visits = make(map[string]int, len(pb.Steps))
+	}
+	r.slas = pb.SLAs
+
+	i := 0
+	for n := 0; n < maxTransitions; n++ {
+		if i >= len(pb.Steps) {
+			return modal.Resolution{}, temporal.NewNonRetryableApplicationError("playbook ended without a finish step", playbookErrorType, nil)
+		}
+		step := pb.Steps[i]
+		r.visits[step.ID]++
+
+		if step.Kind == playbook.KindFinish {
+			outcome, ok := modal.ParseOutcome(step.Result)
+			if !ok {
+				return modal.Resolution{}, temporal.NewNonRetryableApplicationError(
+					fmt.Sprintf("unknown finish result %q", step.Result), playbookErrorType, nil)
+			}
+			return r.resolve(ctx, step.AuditKind, outcome, step.Message, r.decider), nil
+		}
+
+		outcome, err := r.runStep(ctx, step)
+		if err != nil {
+			return modal.Resolution{}, err
+		}
+		if d := r.decidedBy(step); d != "" {
+			r.decider = d
+		}
+
+		if next := step.Next(outcome); next != "" {
+			i = index[next]
+		} else {
+			i++
+		}
+	}
+	return modal.Resolution{}, temporal.NewNonRetryableApplicationError(
+		fmt.Sprintf("playbook exceeded %d transitions", maxTransitions), playbookErrorType, nil)
+}
+
+func (r *runner) runStep(ctx workflow.Context, step playbook.Step) (string, error) {
+	switch step.Kind {
+	case playbook.KindAction:
+		return r.runAction(ctx, step)
+	case playbook.KindCondition:
+		ok, err := playbook.EvalCondition(step.Condition, r.lookup)
+		if err != nil {
+			return "", temporal.NewNonRetryableApplicationError(err.Error(), playbookErrorType, err)
+		}
+		outcome := strconv.FormatBool(ok)
+		r.audit("CONDITION_EVALUATED", "playbook condition evaluated", map[string]any{
+			"step":      step.ID,
+			"condition": step.Condition,
+			"result":    outcome,
+		})
+		return outcome, nil
+	case playbook.KindHumanGate:
+		return r.awaitHumanDecision(ctx, step)
+	case playbook.KindRefund:
+		return r.runRefund(ctx, step)
+	case playbook.KindParallel:
+		return r.runParallel(ctx, step)
+	case playbook.KindChildWorkflow:
+		return r.runChild(ctx, step)
+	case playbook.KindWait:
+		return r.runWait(ctx, step)
+	default:
+		return "", temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("unsupported step kind %q", step.Kind), playbookErrorType, nil)
+	}
+}
+
+// runAction runs an action step, repeating it while the retry policy allows.
+func (r *runner) runAction(ctx workflow.Context, step playbook.Step) (string, error) {
+	fn, ok := actions[step.Action]
+	if !ok {
+		return "", temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("unknown action %q", step.Action), playbookErrorType, nil)
+	}
+	stageAction.mark(ctx)
+	for attempt := 1; ; attempt++ {
+		outcome, err := fn(ctx, r, step, attempt)
+		if err != nil {
+			return "", err
+		}
+		if !step.Retry.ShouldRetry(attempt, outcome) {
+			return outcome, nil
+		}
+		if d := step.Retry.BackoffDuration(); d > 0 {
+			if err := workflow.Sleep(ctx, d); err != nil {
+				return "", err
+			}
+		}
+	}
+}
+
+// awaitHumanDecision creates a human task and blocks until a decision for it arrives.
+func (r *runner) awaitHumanDecision(ctx workflow.Context, step playbook.Step) (string, error) {
+	task := r.newTask(ctx, step, *step.Task)
+	return r.awaitDecision(ctx, task)
+}
+
+// newTask builds the human task for a step from its spec. Tasks start at the tier the order was classified with.
+func (r *runner) newTask(ctx workflow.Context, step playbook.Step, spec playbook.TaskSpec) *modal.HumanTask {
+	tier := r.state.CaseFile.Classification.Tier
+	if tier == "" {
+		tier = modal.Tier1
+	}
+	return &modal.HumanTask{
+		ID:                r.taskID(step.ID),
+		OrderID:           r.orderID,
+		Type:              spec.Type,
+		Title:             spec.Title,
+		Reason:            spec.Reason,
+		CreatedAt:         workflow.Now(ctx),
+		RequiredApprovals: 1,
+		Tier:              tier,
+	}
+}
+
+// awaitDecision opens task and blocks until it is rejected or approved by task.RequiredApprovals
+// distinct deciders. A repeat approval from the same decider does not count. Other tasks stay open
+// independently, so parallel branches can each wait on their own task.
+// If the task type has an SLA, the task is reminded, escalated and finally closed with the SLA's
+// default action when nobody decides in time.
+func (r *runner) awaitDecision(ctx workflow.Context, task *modal.HumanTask) (string, error) {
+	stageHumanTask.mark(ctx)
+	var decision modal.TaskDecision
+	cancelled, deadlinePassed := false, false
+	selector := workflow.NewSelector(ctx)
+	stopSLA := r.armSLA(ctx, selector, task, &deadlinePassed)
+	defer stopSLA()
+
+	inbox := r.openTask(ctx, task)
+	selector.AddReceive(inbox, func(c workflow.ReceiveChannel, more bool) {
+		c.Receive(ctx, &decision)
+	})
+	// A parallel step cancels its remaining branches when one of them fails.
+	selector.AddReceive(ctx.Done(), func(c workflow.ReceiveChannel, more bool) {
+		cancelled = true
+	})
+
+	for {
+		decision = modal.TaskDecision{}
+		selector.Select(ctx) // <-- yields; no busy-spin
+		if cancelled {
+			r.closeTask(ctx, task, modal.TaskExpired, "")
+			r.audit("TASK_EXPIRED", "human task closed without a decision", map[string]any{"taskId": task.ID})
+			return "", ctx.Err()
+		}
+		if deadlinePassed {
+			outcome := r.slas[task.Type].DefaultAction
+			r.closeTask(ctx, task, modal.TaskExpired, modal.DecidedBySLA)
+			r.audit("TASK_DEFAULT_APPLIED", "human task passed its hard deadline; default action applied", map[string]any{
+				"taskId":  task.ID,
+				"outcome": outcome,
+			})
+			return outcome, nil
+		}
+		if decision.TaskID == "" {
+			continue // an SLA reminder or escalation fired
+		}
+		if decision.Approved && approvedBy(task, decision.Decider) {
+			r.audit("DUPLICATE_APPROVAL", "approval ignored; decider already approved this task", map[string]any{
+				"taskId":  task.ID,
+				"decider": decision.Decider,
+			})
+			continue
+		}
+
+		outcome := playbook.OutcomeRejected
+		if decision.Approved {
+			outcome = playbook.OutcomeApproved
+			task.Approvals = append(task.Approvals, decision)
+		}
+		r.audit("HUMAN_DECISION", "human decision received", map[string]any{
+			"taskId":    task.ID,
+			"outcome":   outcome,
+			"decider":   decision.Decider,
+			"notes":     decision.Notes,
+			"approvals": len(task.Approvals),
+			"required":  task.RequiredApprovals,
+		})
+		if decision.Approved && len(task.Approvals) < task.RequiredApprovals {
+			continue
+		}
+		status, decidedBy := modal.TaskRejected, decision.Decider
+		if decision.Approved {
+			status, decidedBy = modal.TaskApproved, approvers(task)
+		}
+		r.closeTask(ctx, task, status, decidedBy)
+		return outcome, nil
+	}
+}
+
+// approvers lists the deciders who approved task, in order.
+func approvers(task *modal.HumanTask) string {
+	names := make([]string, len(task.Approvals))
+	for i, a := range task.Approvals {
+		names[i] = a.Decider
+	}
+	return strings.Join(names, ", ")
+}
+
+func approvedBy(task *modal.HumanTask, decider string) bool {
+	for _, a := range task.Approvals {
+		if a.Decider == decider {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup resolves condition fields against the current workflow state.
+func (r *runner) lookup(field string) (string, bool) {
+	cf := r.state.CaseFile
+	switch field {
+	case "orderId":
+		return cf.OrderID, true
+	case "issueType":
+		return string(cf.IssueType), true
+	case "transferStatus":
+		return string(cf.TransferStatus), true
+	case "paymentStatus":
+		return string(cf.PaymentStatus), true
+	case "attemptCount":
+		return strconv.Itoa(cf.AttemptCount), true
+	case "decidedBy":
+		// Empty until a human decides a task on the path taken, e.g. `decidedBy != ""` after an approval gate.
+		return r.decider, true
+	}
+	return "", false
+}
+
+func actionRetryTransfer(ctx workflow.Context, r *runner, step playbook.Step, attempt int) (string, error) {
+	i, req := r.beginAttempt(ctx, "RETRY_TRANSFER", step, attempt)
+	var status modal.TransferStatus
+	if err := workflow.ExecuteActivity(ctx, "RetryTransfer", req).Get(ctx, &status); err != nil {
+		r.endAttempt(i, modal.AttemptFailed)
+		r.audit("ERROR", "RetryTransfer failed", map[string]any{
+			"attempt":        attempt,
+			"idempotencyKey": req.IdempotencyKey,
+			"error":          err.Error(),
+		})
+		// Let the activity retry policy handle transient errors; keep it simple here
+		return "", err
+	}
+	r.endAttempt(i, string(status))
+	if status != modal.TransferAccepted {
+		// The re-sent transfer stays on offer to the buyer; withdraw it if the workflow fails.
+		r.saga.add(cancelTransfer(r.state.Attempts[i]))
+	}
+
+	r.state.CaseFile.TransferStatus = status
+	r.state.CaseFile.AttemptCount = attempt
+	r.audit("RETRY_TRANSFER", "retry transfer executed", map[string]any{
+		"attempt":        attempt,
+		"idempotencyKey": req.IdempotencyKey,
+		"status":         status,
+	})
+	return string(status), nil
+}
+
+// cancelTransfer is the compensation for the transfer sent by the ledger entry forward.
+func cancelTransfer(forward modal.ActionAttemp) compensation {
+	return compensation{
+		actionType: "CANCEL_TRANSFER",
+		activity:   "CancelTransfer",
+		args:       []any{forward},
+		result:     string(modal.TransferCancelled),
+		forward:    forward,
+	}
+}
+
+func actionReauthorizePayment(ctx workflow.Context, r *runner, step playbook.Step, attempt int) (string, error) {
+	i, req := r.beginAttempt(ctx, "REAUTHORIZE_PAYMENT", step, attempt)
+	var res modal.PaymentAuthResult
+	if err := workflow.ExecuteActivity(ctx, "ReauthorizePayment", req).Get(ctx, &res); err != nil {
+		r.endAttempt(i, modal.AttemptFailed)
+		r.audit("ERROR", "ReauthorizePayment failed", map[string]any{
+			"attempt":        attempt,
+			"idempotencyKey": req.IdempotencyKey,
+			"error":          err.Error(),
+		})
+		return "", err
+	}
+	r.endAttempt(i, string(res.Status))
+
+	r.state.CaseFile.PaymentStatus = res.Status
+	r.audit("REAUTHORIZE_PAYMENT", "payment re-authorization executed", map[string]any{
+		"attempt":        attempt,
+		"idempotencyKey": req.IdempotencyKey,
+		"status":         res.Status,
+		"declineCode":    res.DeclineCode,
+	})
+	return string(res.Status), nil
+}
+
+func actionNotifyBuyer(ctx workflow.Context, r *runner, step playbook.Step, attempt int) (string, error) {
+	template := step.Params["template"]
+	i, req := r.beginAttempt(ctx, "NOTIFY_BUYER", step, attempt)
+	if err := workflow.ExecuteActivity(ctx, "NotifyBuyer", req, template).Get(ctx, nil); err != nil {
+		r.endAttempt(i, modal.AttemptFailed)
+		r.audit("ERROR", "NotifyBuyer failed", map[string]any{
+			"template":       template,
+			"idempotencyKey": req.IdempotencyKey,
+			"error":          err.Error(),
+		})
+		// Once the buyer is refunded, failing the run over the message about it would void the refund.
+		if r.saga.registered("VOID_REFUND") && stageRefund.since(ctx, 3) {
+			return modal.AttemptFailed, nil
+		}
+		return "", err
+	}
+	r.endAttempt(i, "SENT")
+
+	r.audit("BUYER_NOTIFIED", "buyer notification sent", map[string]any{
+		"template":       template,
+		"idempotencyKey": req.IdempotencyKey,
+	})
+	return "SENT", nil
+}
+
+func actionPingSupplier(ctx workflow.Context, r *runner, step playbook.Step, attempt int) (string, error) {
+	message := step.Params["message"]
+	i, req := r.beginAttempt(ctx, "PING_SUPPLIER", step, attempt)
+	if err := workflow.ExecuteActivity(ctx, "PingSupplier", req, message).Get(ctx, nil); err != nil {
+		r.endAttempt(i, modal.AttemptFailed)
+		r.audit("ERROR", "PingSupplier failed", map[string]any{
+			"idempotencyKey": req.IdempotencyKey,
+			"error":          err.Error(),
+		})
+		return "", err
+	}
+	r.endAttempt(i, "SENT")
+
+	r.audit("SUPPLIER_PINGED", "supplier pinged", map[string]any{
+		"message":        message,
+		"idempotencyKey": req.IdempotencyKey,
+	})
+	return "SENT", nil
+}
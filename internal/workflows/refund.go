@@ -0,0 +1,100 @@
+package workflows
+
+import (
+	"broken-order-service/internal/modal"
+	"broken-order-service/internal/playbook"
+	"fmt"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// defaultRefundTask is used when a refund step does not configure its own task.
+var defaultRefundTask = playbook.TaskSpec{
+	Type:   modal.TaskRefundApproval,
+	Title:  "Approve refund",
+	Reason: "Review the proposed refund and its policy rationale.",
+}
+
+// runRefund computes a refund proposal, waits for it to be approved (by two distinct deciders above the
+// step's threshold) and only then issues it. Nothing is refunded if the proposal is rejected.
+func (r *runner) runRefund(ctx workflow.Context, step playbook.Step) (string, error) {
+	stageRefund.mark(ctx)
+	var proposal modal.RefundProposal
+	if err := workflow.ExecuteActivity(ctx, "ComputeRefund", r.orderID).Get(ctx, &proposal); err != nil {
+		r.audit("ERROR", "ComputeRefund failed", map[string]any{"error": err.Error()})
+		return "", err
+	}
+	if step.Refund != nil && step.Refund.Basis == playbook.RefundBasisSeats {
+		proposal = r.seatRefund(proposal)
+	}
+	if proposal.AmountCents <= 0 {
+		r.audit("REFUND_NOT_ELIGIBLE", "order is not eligible for a refund", map[string]any{
+			"policy":    proposal.Policy,
+			"rationale": proposal.Rationale,
+		})
+		return playbook.OutcomeNotEligible, nil
+	}
+	r.audit("REFUND_PROPOSED", "refund proposed", map[string]any{
+		"amountCents": proposal.AmountCents,
+		"currency":    proposal.Currency,
+		"policy":      proposal.Policy,
+	})
+
+	spec := defaultRefundTask
+	if step.Task != nil {
+		spec = *step.Task
+		spec.Type = modal.TaskRefundApproval
+	}
+	task := r.newTask(ctx, step, spec)
+	task.Refund = &proposal
+	task.RequiredApprovals = step.Refund.RequiredApprovals(proposal.AmountCents)
+
+	outcome, err := r.awaitDecision(ctx, task)
+	if err != nil || outcome != playbook.OutcomeApproved {
+		return outcome, err
+	}
+
+	i, req := r.beginAttempt(ctx, "ISSUE_REFUND", step, 1)
+	var res modal.RefundResult
+	if err := workflow.ExecuteActivity(ctx, "IssueRefund", req, proposal).Get(ctx, &res); err != nil {
+		r.endAttempt(i, modal.AttemptFailed)
+		r.audit("ERROR", "IssueRefund failed", map[string]any{
+			"idempotencyKey": req.IdempotencyKey,
+			"error":          err.Error(),
+		})
+		return "", err
+	}
+	r.endAttempt(i, res.Status)
+	// A later failure voids the refund. A failed notification about it is not one (see actionNotifyBuyer); version 2
+	// registered no compensation at all.
+	if stageRefund.version(ctx) != 2 {
+		r.saga.add(compensation{
+			actionType: "VOID_REFUND",
+			activity:   "VoidRefund",
+			args:       []any{res},
+			result:     modal.RefundVoided,
+			forward:    r.state.Attempts[i],
+		})
+	}
+
+	r.audit("REFUND_ISSUED", "refund issued", map[string]any{
+		"refundId":       res.RefundID,
+		"amountCents":    res.AmountCents,
+		"currency":       res.Currency,
+		"idempotencyKey": req.IdempotencyKey,
+	})
+	return playbook.OutcomeRefunded, nil
+}
+
+// seatRefund caps a proposal at the value of the seats the buyer did not get as sold, so a partial delivery is
+// only refunded in part.
+func (r *runner) seatRefund(proposal modal.RefundProposal) modal.RefundProposal {
+	d := r.state.CaseFile.CompareSeats()
+	if d.ValueCents < proposal.AmountCents {
+		proposal.Rationale = fmt.Sprintf("%d of %d seats not delivered as sold: refund %s of the %s the %s policy allows",
+			len(d.Missing), len(r.state.CaseFile.ExpectedSeats), modal.FormatAmount(d.ValueCents, d.Currency),
+			modal.FormatAmount(proposal.AmountCents, proposal.Currency), proposal.Policy)
+		proposal.AmountCents = d.ValueCents
+	}
+	return proposal
+}
@@ -0,0 +1,119 @@
+package workflows
+
+import (
+	"broken-order-service/internal/modal"
+	"broken-order-service/internal/playbook"
+	"fmt"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// The replacement actions finish what a SourceReplacement child started: the parent buys the seats it reserved
+// and transfers them to the buyer, or releases them when the replacement is not wanted. The child registered
+// the release with the parent's saga; buying or releasing the seats settles the reservation and drops it.
+
+// replacement returns the seats a SourceReplacement child reserved for the order.
+func (r *runner) replacement(step playbook.Step) (modal.Reservation, error) {
+	res := r.state.CaseFile.Replacement
+	if res == nil {
+		return modal.Reservation{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("step %q: no replacement seats have been reserved", step.ID), playbookErrorType, nil)
+	}
+	return *res, nil
+}
+
+// settleReplacement records the reservation's new status and drops its release from the saga: purchased seats
+// can no longer be released, and released ones need not be.
+func (r *runner) settleReplacement(res modal.Reservation) {
+	if i := reservedBy(r.state.Attempts, r.state.CaseFile.Replacement); i >= 0 {
+		r.saga.drop(r.state.Attempts[i].IdempotencyKey)
+	}
+	r.state.CaseFile.Replacement = &res
+}
+
+func actionPurchaseReplacement(ctx workflow.Context, r *runner, step playbook.Step, attempt int) (string, error) {
+	held, err := r.replacement(step)
+	if err != nil {
+		return "", err
+	}
+	i, req := r.beginAttempt(ctx, "PURCHASE_SEATS", step, attempt)
+	var res modal.Reservation
+	if err := workflow.ExecuteActivity(ctx, "PurchaseSeats", req, held).Get(ctx, &res); err != nil {
+		r.endAttempt(i, modal.AttemptFailed)
+		r.audit("ERROR", "PurchaseSeats failed", map[string]any{
+			"reservationId":  held.ReservationID,
+			"idempotencyKey": req.IdempotencyKey,
+			"error":          err.Error(),
+		})
+		return "", err
+	}
+	r.endAttempt(i, res.Status)
+	// A purchase cannot be undone, so it registers no compensation of its own.
+	r.settleReplacement(res)
+
+	r.audit("SEATS_PURCHASED", "replacement seats purchased", map[string]any{
+		"reservationId":  res.ReservationID,
+		"listingId":      res.Listing.ListingID,
+		"priceCents":     res.Listing.PriceCents,
+		"currency":       res.Listing.Currency,
+		"idempotencyKey": req.IdempotencyKey,
+	})
+	return res.Status, nil
+}
+
+func actionTransferReplacement(ctx workflow.Context, r *runner, step playbook.Step, attempt int) (string, error) {
+	bought, err := r.replacement(step)
+	if err != nil {
+		return "", err
+	}
+	i, req := r.beginAttempt(ctx, "TRANSFER_REPLACEMENT", step, attempt)
+	var status modal.TransferStatus
+	if err := workflow.ExecuteActivity(ctx, "TransferReplacement", req, bought).Get(ctx, &status); err != nil {
+		r.endAttempt(i, modal.AttemptFailed)
+		r.audit("ERROR", "TransferReplacement failed", map[string]any{
+			"reservationId":  bought.ReservationID,
+			"idempotencyKey": req.IdempotencyKey,
+			"error":          err.Error(),
+		})
+		return "", err
+	}
+	r.endAttempt(i, string(status))
+	if status != modal.TransferAccepted {
+		r.saga.add(cancelTransfer(r.state.Attempts[i]))
+	}
+
+	r.state.CaseFile.TransferStatus = status
+	r.audit("REPLACEMENT_TRANSFERRED", "replacement seats transferred to the buyer", map[string]any{
+		"reservationId":  bought.ReservationID,
+		"idempotencyKey": req.IdempotencyKey,
+		"status":         status,
+	})
+	return string(status), nil
+}
+
+func actionReleaseReplacement(ctx workflow.Context, r *runner, step playbook.Step, attempt int) (string, error) {
+	held, err := r.replacement(step)
+	if err != nil {
+		return "", err
+	}
+	i, req := r.beginAttempt(ctx, "RELEASE_SEATS", step, attempt)
+	if err := workflow.ExecuteActivity(ctx, "ReleaseSeats", req, held).Get(ctx, nil); err != nil {
+		r.endAttempt(i, modal.AttemptFailed)
+		r.audit("ERROR", "ReleaseSeats failed", map[string]any{
+			"reservationId":  held.ReservationID,
+			"idempotencyKey": req.IdempotencyKey,
+			"error":          err.Error(),
+		})
+		return "", err
+	}
+	r.endAttempt(i, modal.ReservationReleased)
+	held.Status = modal.ReservationReleased
+	r.settleReplacement(held)
+
+	r.audit("SEATS_RELEASED", "replacement seats released", map[string]any{
+		"reservationId":  held.ReservationID,
+		"idempotencyKey": req.IdempotencyKey,
+	})
+	return modal.ReservationReleased, nil
+}
@@ -0,0 +1,30 @@
+package workflows
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/worker"
+)
+
+// historiesDir holds ResolveBrokenOrder histories recorded with cmd/exporthistories.
+var historiesDir = filepath.Join("..", "..", "testdata", "histories")
+
+// TestReplayRecordedHistories replays every recorded history against the current workflow code. A failure means a
+// change would break workflows already running with that history (e.g. ones waiting on a human decision): gate the
+// change with workflow.GetVersion instead.
+func TestReplayRecordedHistories(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(historiesDir, "*.json"))
+	require.NoError(t, err)
+	// An empty directory would pass without replaying anything, so it is an error rather than a skip.
+	require.NotEmpty(t, files, "no histories in %s; record some with go run ./cmd/exporthistories", historiesDir)
+
+	for _, f := range files {
+		t.Run(filepath.Base(f), func(t *testing.T) {
+			replayer := worker.NewWorkflowReplayer()
+			replayer.RegisterWorkflow(ResolveBrokenOrder)
+			require.NoError(t, replayer.ReplayWorkflowHistoryFromJSONFile(nil, f))
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package workflows
+
+import (
+	"broken-order-service/internal/modal"
+	"broken-order-service/internal/playbook"
+	"slices"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// resolve builds the workflow's result. Every way the workflow can end goes through here, so each outcome is
+// audited the same way (kind DONE unless the playbook names another) and recorded as the ResolutionStatus.
+func (r *runner) resolve(ctx workflow.Context, kind string, outcome modal.Outcome, reason, decidedBy string) modal.Resolution {
+	if kind == "" {
+		kind = "DONE"
+	}
+	if decidedBy == "" {
+		decidedBy = modal.DecidedByAutomation
+	}
+	res := modal.Resolution{
+		Outcome:   outcome,
+		Reason:    reason,
+		DecidedBy: decidedBy,
+		Actions:   slices.Clone(r.state.Attempts),
+	}
+	r.audit(kind, reason, map[string]any{
+		"outcome":   outcome,
+		"decidedBy": decidedBy,
+		"actions":   len(res.Actions),
+	})
+	r.setStatus(ctx, string(outcome))
+	return res
+}
+
+// decidedBy returns who closed the task step opened most recently, or "" if it opened none.
+// A parallel step is decided by its first branch, which also drives its transition.
+func (r *runner) decidedBy(step playbook.Step) string {
+	if step.Kind == playbook.KindParallel {
+		return r.decidedBy(r.steps[step.Branches[0]])
+	}
+	if t, ok := r.state.Tasks[r.taskID(step.ID)]; ok {
+		return t.DecidedBy
+	}
+	return ""
+}
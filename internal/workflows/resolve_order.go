@@ -2,6 +2,8 @@ package workflows
 
 import (
 	"broken-order-service/internal/modal"
+	"broken-order-service/internal/playbook"
+	"fmt"
 	"time"
 
 	"go.temporal.io/sdk/temporal"
@@ -10,139 +12,165 @@ import (
 
 const TaskQueue = "BROKEN_ORDER_TASK_QUEUE"
 const TaskDecisionSignal = "TASK_DECISION_SIGNAL"
+const TaskClaimSignal = "TASK_CLAIM_SIGNAL"
+
+// TaskDecisionUpdate is the synchronous alternative to TaskDecisionSignal: invalid decisions are
+// rejected to the caller, and accepted ones return the resulting task status (see decision_update.go).
+const TaskDecisionUpdate = "task_decision"
 
 type workflowState struct {
-	CaseFile    modal.CaseFile     `json:"caseFile"`
-	PendingTask *modal.HumanTask   `json:"pendingTask,omitempty"`
-	Audit       []modal.AuditEvent `json:"audit,omitempty"`
+	CaseFile modal.CaseFile `json:"caseFile"`
+	// Tasks holds every human task the workflow has opened, keyed by task ID (see tasks.go).
+	Tasks map[string]*modal.HumanTask `json:"tasks,omitempty"`
+	Audit []modal.AuditEvent          `json:"audit,omitempty"`
+	// Attempts is the ledger of every side effect the playbook has executed (see ledger.go).
+	Attempts []modal.ActionAttemp `json:"attempts,omitempty"`
 }
 
-func ResolveBrokenOrder(ctx workflow.Context, orderID string) (string, error) {
-	logger := workflow.GetLogger(ctx)
-	logger.Info("workflow started", "orderID", orderID)
+// Error and retry policy:
+// Timeout: if activity doesn't complete in 10s, assume it failed and retry.
+// Retries: retry up to 3 times with exponential backoff (1s, 2s, 4s) before failing workflow.
+// Side effects that succeeded before a failure are compensated (see saga.go).
+var activityOptions = workflow.ActivityOptions{
+	StartToCloseTimeout: 10 * time.Second,
+	RetryPolicy: &temporal.RetryPolicy{
+		InitialInterval:    1 * time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumAttempts:    3,
+	},
+}
 
-	// Initialize workflow state and helper for appending audit events.
-	state := &workflowState{
+func newWorkflowState() *workflowState {
+	return &workflowState{
+		Tasks: make(map[string]*modal.HumanTask),
 		Audit: make([]modal.AuditEvent, 0),
 	}
+}
 
-	appendAudit := func(kind, message string, data map[string]any) {
-		state.Audit = append(state.Audit, modal.AuditEvent{
+// auditFunc returns the helper that appends to the audit log.
+func (s *workflowState) auditFunc(ctx workflow.Context) func(kind, message string, data map[string]any) {
+	return func(kind, message string, data map[string]any) {
+		s.Audit = append(s.Audit, modal.AuditEvent{
 			At:      workflow.Now(ctx),
 			Kind:    kind,
 			Message: message,
 			Data:    data,
 		})
 	}
+}
 
-	// Queries for API to read casefile/tasks/audit without extra DB
+// registerQueries lets the API read the casefile/tasks/audit without an extra DB.
+func (s *workflowState) registerQueries(ctx workflow.Context) {
 	_ = workflow.SetQueryHandler(ctx, "casefile", func() (modal.CaseFile, error) {
-		return state.CaseFile, nil
+		return s.CaseFile, nil
 	})
 
+	// pending_task predates concurrent tasks; it returns the oldest task still awaiting a decision.
 	_ = workflow.SetQueryHandler(ctx, "pending_task", func() (modal.HumanTask, error) {
-		if state.PendingTask == nil {
-			return modal.HumanTask{}, nil
-		}
-		return *state.PendingTask, nil
+		return s.pendingTask(), nil
+	})
+
+	_ = workflow.SetQueryHandler(ctx, "tasks", func() ([]modal.HumanTask, error) {
+		return s.taskList(), nil
 	})
 
 	_ = workflow.SetQueryHandler(ctx, "audit_log", func() ([]modal.AuditEvent, error) {
-		return state.Audit, nil
+		return s.Audit, nil
 	})
 
-	// Error and retry policy:
-	// Timeout: if activity doesn't complete in 10s, assume it failed and retry.
-	// Retries: retry up to 3 times with exponential backoff (1s, 2s, 4s) before failing workflow.
-	// Note: this is a simple example. In production, we want more robust error handling and compensation logic.
-	ao := workflow.ActivityOptions{
-		StartToCloseTimeout: 10 * time.Second,
-		RetryPolicy: &temporal.RetryPolicy{
-			InitialInterval:    1 * time.Second,
-			BackoffCoefficient: 2.0,
-			MaximumAttempts:    3,
-		},
-	}
-	ctx = workflow.WithActivityOptions(ctx, ao)
+	_ = workflow.SetQueryHandler(ctx, "attempts", func() ([]modal.ActionAttemp, error) {
+		return s.Attempts, nil
+	})
+}
 
-	// Build case file
-	var cf modal.CaseFile
-	if err := workflow.ExecuteActivity(ctx, "BuildCaseFile", orderID).Get(ctx, &cf); err != nil {
-		logger.Error("failed to build casefile", "error", err)
-		return "", err
+// ResolveBrokenOrder handles one broken-order event. Producers start it with StartOptions(ev).
+// It returns how the order was resolved; an error means the workflow failed (ResolutionStatus FAILED).
+func ResolveBrokenOrder(ctx workflow.Context, ev modal.BrokenOrderEvent) (modal.Resolution, error) {
+	logger := workflow.GetLogger(ctx)
+	if err := ev.Validate(); err != nil {
+		return modal.Resolution{}, temporal.NewNonRetryableApplicationError("invalid broken order event: "+err.Error(), "InvalidEvent", err)
 	}
-	state.CaseFile = cf
-	appendAudit("CASEFILE_BUILT", "Case file built for order", map[string]any{
-		"issueType": cf.IssueType,
-	})
+	orderID := ev.OrderID
+	logger.Info("workflow started", "orderID", orderID, "eventID", ev.EventID, "source", ev.Source)
 
-	// Simple playbook (hardcoded for prototype): if issue is TRANSFER_FAILED, create human task to retry transfer.
-	// In a real system, this would be more complex with branching logic, multiple task types, etc.
-	if cf.IssueType == modal.IssueTransferFailed {
-		const maxAttempts = 3
-		for attempt := 1; attempt <= maxAttempts; attempt++ {
-			if state.CaseFile.TransferStatus == modal.TransferAccepted {
-				appendAudit("RESOLVED", "transfer already accepted", nil)
-				return "RESOLVED_AUTOMATICALLY", nil
-			}
-
-			var status modal.TransferStatus
-			if err := workflow.ExecuteActivity(ctx, "RetryTransfer", orderID, attempt).Get(ctx, &status); err != nil {
-				appendAudit("ERROR", "RetryTransfer failed", map[string]any{
-					"attempt": attempt,
-					"error":   err.Error(),
-				})
-				// Let the workflow retry behavior handle transient errors; keep it simple here
-				return "", err
-			}
-
-			state.CaseFile.TransferStatus = status
-			state.CaseFile.AttemptCount = attempt
-			appendAudit("RETRY_TRANSFER", "retry transfer executed", map[string]any{
-				"attempt": attempt,
-				"status":  status,
-			})
-
-			if status == modal.TransferAccepted {
-				appendAudit("RESOLVED", "transfer accepted after retries", map[string]any{"attempt": attempt})
-				return "RESOLVED_AUTOMATICALLY", nil
-			}
+	// Producers set OrderId at start; executions started by hand (e.g. from the Temporal CLI) get it here.
+	if _, ok := workflow.GetTypedSearchAttributes(ctx).GetKeyword(OrderIDKey); !ok {
+		if err := workflow.UpsertTypedSearchAttributes(ctx, OrderIDKey.ValueSet(orderID)); err != nil {
+			return modal.Resolution{}, err
 		}
+	}
 
-		// If Still failing after retries, create human task for manual review (not implemented in this prototype).
-		task := &modal.HumanTask{
-			ID:        "task-" + orderID,
-			OrderID:   orderID,
-			Type:      "RETRY_TRANSFER",
-			Title:     "Please check failed transfer",
-			Reason:    "Automated retries failed to resolve transfer issue. Please investigate and take necessary actions.",
-			CreatedAt: workflow.Now(ctx),
-		}
-		state.PendingTask = task
-		appendAudit("HUMAN_TASK_CREATED", "created human task for manual review after retries", nil)
-		logger.Info("human task created for manual review after retries", "orderID", orderID)
+	// Initialize workflow state and helper for appending audit events.
+	state := newWorkflowState()
+	appendAudit := state.auditFunc(ctx)
+	state.registerQueries(ctx)
+
+	// Human tasks: decisions and claims are routed to open tasks by task ID from here on.
+	r := &runner{
+		workflowID: workflow.GetInfo(ctx).WorkflowExecution.ID,
+		orderID:    orderID,
+		state:      state,
+		audit:      appendAudit,
+		visits:     make(map[string]int),
+	}
+	r.routeTaskSignals(ctx)
+	if err := r.registerDecisionUpdate(ctx); err != nil {
+		return modal.Resolution{}, err
+	}
+	r.setStatus(ctx, StatusInProgress)
 
-		var decision modal.TaskDecision
-		selector := workflow.NewSelector(ctx)
-		sigCh := workflow.GetSignalChannel(ctx, TaskDecisionSignal)
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
 
-		selector.AddReceive(sigCh, func(c workflow.ReceiveChannel, more bool) {
-			c.Receive(ctx, &decision)
-		})
+	// Build case file: fan out to every context source concurrently (see gather.go).
+	// Each stage is marked with its workflow.GetVersion change ID on entry (see versioning.go).
+	stageGatherCaseFile.mark(ctx)
+	state.CaseFile = gatherCaseFile(ctx, ev, appendAudit)
+	appendAudit("CASEFILE_BUILT", "Case file built for order", map[string]any{
+		"eventId":           ev.EventID,
+		"reportedIssueType": ev.ReportedIssueType,
+		"sections":          state.CaseFile.Sections,
+	})
 
-		for decision.TaskID != task.ID {
-			selector.Select(ctx) // <-- yields; no busy-spin
-		}
-		if decision.Approved {
-			appendAudit("DONE", "workflow completed after human decision", map[string]any{"result": "ESCALATED_APPROVED"})
-			return "ESCALATED_APPROVED", nil
-		}
-		return "PENDING_MANUAL_REVIEW", nil
+	// Classify the issue (see classify.go); orders the classifier is unsure about are triaged by a human first.
+	ok, err := r.classify(ctx)
+	if err != nil {
+		r.setStatus(ctx, StatusFailed)
+		return modal.Resolution{}, err
+	}
+	if !ok {
+		return r.resolve(ctx, "", modal.OutcomeRejected, "triage rejected the suggested issue type; order needs manual review",
+			r.decidedBy(playbook.Step{ID: triageStepID})), nil
+	}
 
+	// Load the playbook for this issue type. Playbooks are config (see internal/playbook),
+	// loaded through an activity so the version used is recorded in history.
+	stageLoadPlaybook.mark(ctx)
+	var pb playbook.Playbook
+	if err := workflow.ExecuteActivity(ctx, "LoadPlaybook", state.CaseFile.IssueType).Get(ctx, &pb); err != nil {
+		logger.Error("failed to load playbook", "error", err)
+		r.setStatus(ctx, StatusFailed)
+		return modal.Resolution{}, err
 	}
 
-	// For other issue types, we can add more logic here. For now, just return resolved for unsupported issue types.
+	// Issue types without a playbook are handed back untouched; more playbooks can be added in config.
+	if pb.Empty() {
+		return r.resolve(ctx, "", modal.OutcomeUnsupported,
+			fmt.Sprintf("no playbook for issue type %s; nothing was attempted", state.CaseFile.IssueType), ""), nil
+	}
+	appendAudit("PLAYBOOK_LOADED", "playbook loaded for issue type", map[string]any{
+		"issueType": pb.IssueType,
+		"version":   pb.Version,
+	})
 
-	appendAudit("DONE", "workflow completed after human decision", map[string]any{"result": "ESCALATED_REJECTED"})
-	return "ESCALATED_REJECTED", nil
+	res, err := r.run(ctx, pb)
+	// The run may have ended through cancellation; its side effects are still compensated and the final
+	// status recorded.
+	if err != nil {
+		dctx, _ := workflow.NewDisconnectedContext(ctx)
+		r.compensate(dctx, err)
+		r.setStatus(dctx, StatusFailed)
+	}
+	// Let in-flight decision updates return their result before the workflow completes.
+	_ = workflow.Await(ctx, func() bool { return workflow.AllHandlersFinished(ctx) })
+	return res, err
 }
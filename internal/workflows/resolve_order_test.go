@@ -0,0 +1,984 @@
+package workflows
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"broken-order-service/internal/activities"
+	"broken-order-service/internal/adapters"
+	"broken-order-service/internal/modal"
+	"broken-order-service/internal/playbook"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+const testOrderID = "ORDER-1"
+
+// testSeats are the seats sold on the test order.
+var testSeats = []modal.Seat{
+	{Section: "101", Row: "A", Number: "1"},
+	{Section: "101", Row: "A", Number: "2"},
+	{Section: "101", Row: "A", Number: "3"},
+}
+
+// testWorkflowID is the workflow ID the SDK test environment runs executions with.
+const testWorkflowID = "default-test-workflow-id"
+
+// resolveOrderSuite runs ResolveBrokenOrder in the SDK test environment. Context and side-effecting
+// activities are mocked from the fields below, which each test adjusts before executing the workflow;
+// classification and playbook loading run for real against the built-in playbooks.
+type resolveOrderSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+	env *testsuite.TestWorkflowEnvironment
+
+	transfer modal.TransferContext
+	payment  modal.PaymentContext
+	comms    []modal.SupplierMessage
+	// retryResults[i] is the outcome of transfer retry attempt i+1; later attempts are NOT_ACCEPTED.
+	retryResults []modal.TransferStatus
+	// reauthResults[i] is the outcome of re-authorization attempt i+1; later attempts repeat the last.
+	reauthResults []modal.PaymentStatus
+	refund        modal.RefundProposal
+	// notifyErr and voidErr fail every NotifyBuyer and VoidRefund call.
+	notifyErr error
+	voidErr   error
+	// listings is what every replacement search finds; reserveErrs fails the reservation of a listing by ID.
+	listings    []modal.SeatListing
+	reserveErrs map[string]error
+	searches    int
+	// band is the price band of the last replacement search.
+	band modal.PriceBand
+	// corrections[i] is the supplier's answer to correction request i+1; later requests are DECLINED.
+	corrections []string
+}
+
+func TestResolveOrderSuite(t *testing.T) {
+	suite.Run(t, new(resolveOrderSuite))
+}
+
+func (s *resolveOrderSuite) SetupTest() {
+	s.transfer = modal.TransferContext{Status: modal.TransferNotAccepted}
+	s.payment = modal.PaymentContext{Authorization: modal.PaymentAuthResult{Status: modal.PaymentAuthorized}}
+	s.comms = nil
+	s.retryResults = nil
+	s.reauthResults = nil
+	s.refund = modal.RefundProposal{AmountCents: 12000, Currency: "USD", Policy: "FULL_REFUND"}
+	s.notifyErr = nil
+	s.voidErr = nil
+	s.listings = nil
+	s.reserveErrs = nil
+	s.searches = 0
+	s.band = modal.PriceBand{}
+	s.corrections = nil
+
+	s.env = s.NewTestWorkflowEnvironment()
+	a := activities.NewFakeActivities(adapters.NewFakeFromScenario(&adapters.Scenario{}), nil)
+	s.env.RegisterActivity(a)
+	s.env.RegisterWorkflow(SourceReplacement)
+	s.env.RegisterWorkflow(NotifyBuyer)
+
+	s.env.OnActivity(a.FetchOrder, mock.Anything, mock.Anything).Return(modal.OrderDetails{
+		OrderID:     testOrderID,
+		BuyerEmail:  "buyer@example.com",
+		AmountCents: 12000,
+		Currency:    "USD",
+		Seats:       testSeats,
+	}, nil)
+	s.env.OnActivity(a.FetchTransfer, mock.Anything, mock.Anything).Return(
+		func(context.Context, string) (modal.TransferContext, error) { return s.transfer, nil })
+	s.env.OnActivity(a.FetchSupplierComms, mock.Anything, mock.Anything).Return(
+		func(context.Context, string) ([]modal.SupplierMessage, error) { return s.comms, nil })
+	s.env.OnActivity(a.FetchPayment, mock.Anything, mock.Anything).Return(
+		func(context.Context, string) (modal.PaymentContext, error) { return s.payment, nil })
+
+	s.env.OnActivity(a.RetryTransfer, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, req modal.ActionAttemp) (modal.TransferStatus, error) {
+			if req.Attempt <= len(s.retryResults) {
+				return s.retryResults[req.Attempt-1], nil
+			}
+			return modal.TransferNotAccepted, nil
+		})
+	s.env.OnActivity(a.ReauthorizePayment, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, req modal.ActionAttemp) (modal.PaymentAuthResult, error) {
+			i := min(req.Attempt, len(s.reauthResults)) - 1
+			return modal.PaymentAuthResult{Status: s.reauthResults[i]}, nil
+		})
+	s.env.OnActivity(a.NotifyBuyer, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(context.Context, modal.ActionAttemp, string) error { return s.notifyErr })
+	s.env.OnActivity(a.NotifyExpectedDelivery, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(context.Context, modal.ActionAttemp, string, time.Time) error { return s.notifyErr })
+	s.env.OnActivity(a.PingSupplier, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	s.env.OnActivity(a.RequestSeatCorrection, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, req modal.ActionAttemp, _ modal.SeatDiscrepancy) (string, error) {
+			if req.Attempt <= len(s.corrections) {
+				return s.corrections[req.Attempt-1], nil
+			}
+			return modal.CorrectionDeclined, nil
+		})
+	s.env.OnActivity(a.ComputeRefund, mock.Anything, mock.Anything).Return(
+		func(context.Context, string) (modal.RefundProposal, error) { return s.refund, nil })
+	s.env.OnActivity(a.IssueRefund, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, req modal.ActionAttemp, p modal.RefundProposal) (modal.RefundResult, error) {
+			return modal.RefundResult{RefundID: req.OrderID + "-refund-1", AmountCents: p.AmountCents, Currency: p.Currency, Status: modal.RefundIssued}, nil
+		})
+	s.env.OnActivity(a.FindReplacementSeats, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, _ string, band modal.PriceBand) ([]modal.SeatListing, error) {
+			s.searches++
+			s.band = band
+			return s.listings, nil
+		})
+	s.env.OnActivity(a.ReserveSeats, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, req modal.ActionAttemp, l modal.SeatListing) (modal.Reservation, error) {
+			if err := s.reserveErrs[l.ListingID]; err != nil {
+				return modal.Reservation{}, err
+			}
+			return modal.Reservation{ReservationID: "RES-" + l.ListingID, OrderID: req.OrderID, Listing: l, Status: modal.ReservationHeld}, nil
+		})
+	s.env.OnActivity(a.ReleaseSeats, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	s.env.OnActivity(a.PurchaseSeats, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, _ modal.ActionAttemp, res modal.Reservation) (modal.Reservation, error) {
+			res.Status = modal.ReservationPurchased
+			return res, nil
+		})
+	s.env.OnActivity(a.TransferReplacement, mock.Anything, mock.Anything, mock.Anything).Return(modal.TransferAccepted, nil)
+	s.env.OnActivity(a.CancelTransfer, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	s.env.OnActivity(a.VoidRefund, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, _ modal.ActionAttemp, res modal.RefundResult) (modal.RefundResult, error) {
+			res.Status = modal.RefundVoided
+			return res, s.voidErr
+		})
+}
+
+func (s *resolveOrderSuite) execute() {
+	s.env.ExecuteWorkflow(ResolveBrokenOrder, modal.BrokenOrderEvent{EventID: "evt-1", OrderID: testOrderID})
+	s.True(s.env.IsWorkflowCompleted())
+}
+
+func (s *resolveOrderSuite) requireResult(want modal.Outcome) modal.Resolution {
+	s.Require().NoError(s.env.GetWorkflowError())
+	var got modal.Resolution
+	s.Require().NoError(s.env.GetWorkflowResult(&got))
+	s.Equal(want, got.Outcome)
+	return got
+}
+
+// decideAfter signals a decision for the task opened by stepID, d after the workflow started.
+func (s *resolveOrderSuite) decideAfter(d time.Duration, stepID string, approved bool, decider string) {
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(TaskDecisionSignal, modal.TaskDecision{
+			TaskID:   "task-" + testOrderID + "-" + stepID,
+			Approved: approved,
+			Decider:  decider,
+		})
+	}, d)
+}
+
+func (s *resolveOrderSuite) tasks() []modal.HumanTask {
+	v, err := s.env.QueryWorkflow("tasks")
+	s.Require().NoError(err)
+	var tasks []modal.HumanTask
+	s.Require().NoError(v.Get(&tasks))
+	return tasks
+}
+
+func (s *resolveOrderSuite) attempts() []modal.ActionAttemp {
+	v, err := s.env.QueryWorkflow("attempts")
+	s.Require().NoError(err)
+	var attempts []modal.ActionAttemp
+	s.Require().NoError(v.Get(&attempts))
+	return attempts
+}
+
+func (s *resolveOrderSuite) auditKinds() []string {
+	v, err := s.env.QueryWorkflow("audit_log")
+	s.Require().NoError(err)
+	var audit []modal.AuditEvent
+	s.Require().NoError(v.Get(&audit))
+	kinds := make([]string, 0, len(audit))
+	for _, e := range audit {
+		kinds = append(kinds, e.Kind)
+	}
+	return kinds
+}
+
+func actionTypes(attempts []modal.ActionAttemp) []string {
+	types := make([]string, 0, len(attempts))
+	for _, a := range attempts {
+		types = append(types, a.ActionType)
+	}
+	return types
+}
+
+func taskTypes(tasks []modal.HumanTask) []string {
+	types := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		types = append(types, t.Type)
+	}
+	return types
+}
+
+// Transfer playbook.
+
+func (s *resolveOrderSuite) TestTransferAlreadyAccepted() {
+	s.transfer.Status = modal.TransferAccepted
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeResolvedAutomatically)
+	s.Equal(modal.DecidedByAutomation, res.DecidedBy)
+	s.Equal("transfer already accepted", res.Reason)
+	s.Empty(res.Actions)
+	s.Empty(s.attempts())
+	s.Empty(s.tasks())
+}
+
+func (s *resolveOrderSuite) TestTransferAcceptedOnSecondAttempt() {
+	s.retryResults = []modal.TransferStatus{modal.TransferNotAccepted, modal.TransferAccepted}
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeResolvedAutomatically)
+	attempts := s.attempts()
+	s.Equal(attempts, res.Actions)
+	s.Equal([]string{"RETRY_TRANSFER", "RETRY_TRANSFER"}, actionTypes(attempts))
+	s.Equal("default-test-workflow-id/retry-transfer/2", attempts[1].IdempotencyKey)
+	s.Equal(string(modal.TransferAccepted), attempts[1].Result)
+	s.Empty(s.tasks())
+}
+
+func (s *resolveOrderSuite) TestHumanTaskAfterThreeFailedRetriesApproved() {
+	s.env.RegisterDelayedCallback(func() {
+		v, err := s.env.QueryWorkflow("pending_task")
+		s.Require().NoError(err)
+		var task modal.HumanTask
+		s.Require().NoError(v.Get(&task))
+		s.Equal(modal.TaskRetryTransfer, task.Type)
+		s.Equal(modal.TaskOpen, task.Status)
+		s.Equal(modal.Tier1, task.Tier)
+	}, time.Minute)
+	s.decideAfter(2*time.Minute, "review-transfer", true, "alice")
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeEscalatedApproved)
+	s.Equal("alice", res.DecidedBy)
+	s.Equal([]string{"RETRY_TRANSFER", "RETRY_TRANSFER", "RETRY_TRANSFER", "PING_SUPPLIER"}, actionTypes(s.attempts()))
+	tasks := s.tasks()
+	s.Require().Len(tasks, 1)
+	s.Equal(modal.TaskApproved, tasks[0].Status)
+}
+
+func (s *resolveOrderSuite) TestRejectedReviewIssuesRefund() {
+	s.decideAfter(time.Minute, "review-transfer", false, "alice")
+	s.decideAfter(2*time.Minute, "refund-buyer", true, "alice")
+	s.decideAfter(3*time.Minute, "escalate-supplier", true, "bob")
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeRefunded)
+	s.Equal("alice", res.DecidedBy) // the refund approver, not the supplier escalation
+	s.Equal([]string{"RETRY_TRANSFER", "RETRY_TRANSFER", "RETRY_TRANSFER", "PING_SUPPLIER", "ISSUE_REFUND", "NOTIFY_BUYER"},
+		actionTypes(s.attempts()))
+	s.Equal([]string{modal.TaskRetryTransfer, "SUPPLIER_ESCALATION", modal.TaskRefundApproval}, taskTypes(s.tasks()))
+}
+
+func (s *resolveOrderSuite) TestRefundAboveThresholdNeedsTwoApprovers() {
+	s.refund.AmountCents = 51000
+	s.decideAfter(time.Minute, "review-transfer", false, "alice")
+	s.decideAfter(2*time.Minute, "escalate-supplier", true, "bob")
+	s.decideAfter(3*time.Minute, "refund-buyer", true, "alice")
+	s.decideAfter(4*time.Minute, "refund-buyer", true, "alice") // repeat approval does not count
+	s.env.RegisterDelayedCallback(func() {
+		for _, t := range s.tasks() {
+			if t.Type == modal.TaskRefundApproval {
+				s.Equal(2, t.RequiredApprovals)
+				s.Len(t.Approvals, 1)
+				s.True(t.Status.Active())
+			}
+		}
+	}, 5*time.Minute)
+	s.decideAfter(6*time.Minute, "refund-buyer", true, "carol")
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeRefunded)
+	s.Equal("alice, carol", res.DecidedBy)
+	s.Contains(s.auditKinds(), "DUPLICATE_APPROVAL")
+}
+
+func (s *resolveOrderSuite) TestRefundRejected() {
+	s.decideAfter(time.Minute, "review-transfer", false, "alice")
+	s.decideAfter(2*time.Minute, "escalate-supplier", true, "bob")
+	s.decideAfter(3*time.Minute, "refund-buyer", false, "alice")
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeRejected)
+	s.Equal("alice", res.DecidedBy)
+	s.NotContains(actionTypes(s.attempts()), "ISSUE_REFUND")
+}
+
+func (s *resolveOrderSuite) TestRefundNotEligible() {
+	s.refund = modal.RefundProposal{Currency: "USD", Policy: "NO_REFUND"}
+	s.decideAfter(time.Minute, "review-transfer", false, "alice")
+	s.decideAfter(2*time.Minute, "escalate-supplier", true, "bob")
+	s.execute()
+
+	s.requireResult(modal.OutcomeManualFollowUp)
+	s.Contains(s.auditKinds(), "REFUND_NOT_ELIGIBLE")
+	s.Equal([]string{modal.TaskRetryTransfer, "SUPPLIER_ESCALATION"}, taskTypes(s.tasks()))
+}
+
+// Nobody decides: timers are skipped, so the SLA escalations and hard-deadline defaults all fire.
+func (s *resolveOrderSuite) TestSLADefaultsApplyWithoutDecisions() {
+	start := s.env.Now()
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeRejected)
+	s.Equal(modal.DecidedBySLA, res.DecidedBy)
+	kinds := s.auditKinds()
+	s.Contains(kinds, "TASK_REMINDER")
+	s.Contains(kinds, "TASK_ESCALATED")
+	s.Contains(kinds, "TASK_DEFAULT_APPLIED")
+	for _, t := range s.tasks() {
+		s.Equal(modal.TaskExpired, t.Status, t.ID)
+		s.Equal(modal.Tier2, t.Tier, t.ID)
+	}
+	// Review deadline (24h) then the refund deadline (48h) after it.
+	s.GreaterOrEqual(s.env.Now().Sub(start), 72*time.Hour)
+}
+
+// Payment playbook.
+
+func (s *resolveOrderSuite) TestSoftDeclineRecovers() {
+	s.payment.Authorization = modal.PaymentAuthResult{Status: modal.PaymentSoftDeclined, DeclineCode: "insufficient_funds"}
+	s.reauthResults = []modal.PaymentStatus{modal.PaymentSoftDeclined, modal.PaymentAuthorized}
+	s.execute()
+
+	s.requireResult(modal.OutcomeResolvedAutomatically)
+	s.Equal([]string{"REAUTHORIZE_PAYMENT", "REAUTHORIZE_PAYMENT", "NOTIFY_BUYER"}, actionTypes(s.attempts()))
+}
+
+func (s *resolveOrderSuite) TestSoftDeclinePersists() {
+	s.payment.Authorization = modal.PaymentAuthResult{Status: modal.PaymentSoftDeclined}
+	s.reauthResults = []modal.PaymentStatus{modal.PaymentSoftDeclined}
+	s.execute()
+
+	s.requireResult(modal.OutcomeAwaitingBuyerAction)
+	s.Equal([]string{"REAUTHORIZE_PAYMENT", "REAUTHORIZE_PAYMENT", "REAUTHORIZE_PAYMENT", "NOTIFY_BUYER"}, actionTypes(s.attempts()))
+}
+
+func (s *resolveOrderSuite) TestHardDeclineApproved() {
+	s.payment.Authorization = modal.PaymentAuthResult{Status: modal.PaymentHardDeclined}
+	s.reauthResults = []modal.PaymentStatus{modal.PaymentHardDeclined}
+	s.decideAfter(time.Minute, "review-decline", true, "alice")
+	s.execute()
+
+	s.requireResult(modal.OutcomeEscalatedApproved)
+	tasks := s.tasks()
+	s.Require().Len(tasks, 1)
+	s.Equal(modal.Tier2, tasks[0].Tier) // hard declines are classified TIER2
+}
+
+func (s *resolveOrderSuite) TestHardDeclineRejected() {
+	s.payment.Authorization = modal.PaymentAuthResult{Status: modal.PaymentHardDeclined}
+	s.reauthResults = []modal.PaymentStatus{modal.PaymentHardDeclined}
+	s.decideAfter(time.Minute, "review-decline", false, "alice")
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeRejected)
+	s.Equal("alice", res.DecidedBy)
+}
+
+// Classification.
+
+func (s *resolveOrderSuite) TestUnsupportedIssueType() {
+	s.env.OnActivity("ClassifyIssue", mock.Anything, mock.Anything).Return(modal.Classification{
+		IssueType: "EVENT_CANCELLED", Tier: modal.Tier1, Confidence: 0.9, Classifier: "test",
+	}, nil)
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeUnsupported)
+	s.Equal(modal.DecidedByAutomation, res.DecidedBy)
+	s.Empty(s.attempts())
+	kinds := s.auditKinds()
+	s.Equal("DONE", kinds[len(kinds)-1])
+}
+
+func (s *resolveOrderSuite) TestTriageOverridesIssueType() {
+	s.env.OnActivity("ClassifyIssue", mock.Anything, mock.Anything).Return(modal.Classification{
+		IssueType: modal.IssueTransferFailed, Tier: modal.Tier1, Confidence: 0.3, Classifier: "test",
+	}, nil)
+	s.payment.Authorization = modal.PaymentAuthResult{Status: modal.PaymentSoftDeclined}
+	s.reauthResults = []modal.PaymentStatus{modal.PaymentAuthorized}
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(TaskDecisionSignal, modal.TaskDecision{
+			TaskID: "task-" + testOrderID + "-triage", Approved: true, Decider: "alice", IssueType: modal.IssuePaymentFailed,
+		})
+	}, time.Minute)
+	s.execute()
+
+	s.requireResult(modal.OutcomeResolvedAutomatically)
+	s.Equal([]string{modal.TaskTriage}, taskTypes(s.tasks()))
+	s.Contains(s.auditKinds(), "ISSUE_TRIAGED")
+}
+
+func (s *resolveOrderSuite) TestTriageRejected() {
+	s.env.OnActivity("ClassifyIssue", mock.Anything, mock.Anything).Return(modal.Classification{
+		IssueType: modal.IssueTransferFailed, Tier: modal.Tier1, Confidence: 0.3, Classifier: "test",
+	}, nil)
+	s.decideAfter(time.Minute, "triage", false, "alice")
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeRejected)
+	s.Equal("alice", res.DecidedBy)
+	s.Empty(s.attempts())
+}
+
+// Nobody confirms the issue type: the triage SLA escalates the task and then applies the classifier's suggestion.
+func (s *resolveOrderSuite) TestTriageDefaultsToSuggestionWithoutDecision() {
+	s.env.OnActivity("ClassifyIssue", mock.Anything, mock.Anything).Return(modal.Classification{
+		IssueType: modal.IssuePaymentFailed, Tier: modal.Tier1, Confidence: 0.3, Classifier: "test",
+	}, nil)
+	s.payment.Authorization = modal.PaymentAuthResult{Status: modal.PaymentSoftDeclined}
+	s.reauthResults = []modal.PaymentStatus{modal.PaymentAuthorized}
+	start := s.env.Now()
+	s.execute()
+
+	s.requireResult(modal.OutcomeResolvedAutomatically)
+	s.GreaterOrEqual(s.env.Now().Sub(start), 24*time.Hour)
+	s.Equal(modal.IssuePaymentFailed, s.casefile().IssueType)
+	tasks := s.tasks()
+	s.Require().Len(tasks, 1)
+	s.Equal(modal.TaskExpired, tasks[0].Status)
+	s.Equal(modal.DecidedBySLA, tasks[0].DecidedBy)
+	s.Equal(modal.Tier2, tasks[0].Tier)
+	s.Empty(tasks[0].Approvals)
+	kinds := s.auditKinds()
+	s.Contains(kinds, "TASK_ESCALATED")
+	s.Contains(kinds, "TASK_DEFAULT_APPLIED")
+	s.Contains(kinds, "ISSUE_TRIAGED")
+}
+
+// Executions that opened their TRIAGE task before it had an SLA keep waiting for a decision without timers.
+func (s *resolveOrderSuite) TestClassifyStageV1TriageHasNoSLA() {
+	s.env.OnGetVersion(stageClassify.changeID, stageClassify.min, stageClassify.max).Return(workflow.Version(1))
+	s.env.OnActivity("ClassifyIssue", mock.Anything, mock.Anything).Return(modal.Classification{
+		IssueType: modal.IssueTransferFailed, Tier: modal.Tier1, Confidence: 0.3, Classifier: "test",
+	}, nil)
+	s.decideAfter(48*time.Hour, "triage", false, "alice")
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeRejected)
+	s.Equal("alice", res.DecidedBy)
+	tasks := s.tasks()
+	s.Require().Len(tasks, 1)
+	s.True(tasks[0].DueAt.IsZero())
+	s.NotContains(s.auditKinds(), "TASK_ESCALATED")
+}
+
+func (s *resolveOrderSuite) TestInvalidEventFails() {
+	s.env.ExecuteWorkflow(ResolveBrokenOrder, modal.BrokenOrderEvent{EventID: "evt-1"})
+
+	err := s.env.GetWorkflowError()
+	s.Require().Error(err)
+	var appErr *temporal.ApplicationError
+	s.Require().ErrorAs(err, &appErr)
+	s.Equal("InvalidEvent", appErr.Type())
+}
+
+// Human task handling.
+
+// Two decisions accepted together for a single-approval task: the first closes the task, and the second must report
+// that instead of waiting on the closed task forever.
+func (s *resolveOrderSuite) TestConcurrentDecisionUpdatesForOneTask() {
+	taskID := "task-" + testOrderID + "-refund-buyer"
+	var results []error
+	s.decideAfter(time.Minute, "review-transfer", false, "carol")
+	// The supplier escalation stays open meanwhile, so the workflow is still running when the second update is handled.
+	s.decideAfter(3*time.Minute, "escalate-supplier", true, "carol")
+	s.env.RegisterDelayedCallback(func() {
+		for _, d := range []modal.TaskDecision{
+			{TaskID: taskID, Approved: true, Decider: "alice"},
+			{TaskID: taskID, Approved: false, Decider: "bob"},
+		} {
+			s.env.UpdateWorkflow(TaskDecisionUpdate, d.Decider, &testsuite.TestUpdateCallback{
+				OnAccept:   func() {},
+				OnReject:   func(err error) { results = append(results, err) },
+				OnComplete: func(_ any, err error) { results = append(results, err) },
+			}, d)
+		}
+	}, 2*time.Minute)
+	s.execute()
+
+	s.requireResult(modal.OutcomeRefunded)
+	s.Require().Len(results, 2)
+	s.NoError(results[0])
+	var appErr *temporal.ApplicationError
+	s.Require().ErrorAs(results[1], &appErr)
+	s.Equal(ErrTypeTaskClosed, appErr.Type())
+}
+
+// A repeat approval from the same decider is answered as a duplicate, whether the validator or the handler
+// sees the first one counted, rather than waiting for the task to close.
+func (s *resolveOrderSuite) TestRepeatApprovalIsRejectedAsDuplicate() {
+	s.refund.AmountCents = 51000
+	taskID := "task-" + testOrderID + "-refund-buyer"
+	var results []modal.DecisionResult
+	var errs []error
+	s.decideAfter(time.Minute, "review-transfer", false, "carol")
+	s.env.RegisterDelayedCallback(func() {
+		for _, notes := range []string{"first", "second"} {
+			s.env.UpdateWorkflow(TaskDecisionUpdate, notes, &testsuite.TestUpdateCallback{
+				OnAccept: func() {},
+				OnReject: func(err error) { errs = append(errs, err) },
+				OnComplete: func(v any, err error) {
+					if err != nil {
+						errs = append(errs, err)
+						return
+					}
+					results = append(results, v.(modal.DecisionResult))
+				},
+			}, modal.TaskDecision{TaskID: taskID, Approved: true, Decider: "alice", Notes: notes})
+		}
+	}, 2*time.Minute)
+	s.env.RegisterDelayedCallback(func() {
+		s.Len(results, 1, "the first approval is still waiting on the task")
+		s.Len(errs, 1, "the repeat approval is still waiting on the task")
+	}, 2*time.Minute+time.Second)
+	s.decideAfter(3*time.Minute, "refund-buyer", true, "bob")
+	s.decideAfter(4*time.Minute, "escalate-supplier", true, "carol")
+	s.execute()
+
+	s.requireResult(modal.OutcomeRefunded)
+	s.Require().Len(results, 1)
+	s.Equal(1, results[0].Approvals)
+	s.Require().Len(errs, 1)
+	var appErr *temporal.ApplicationError
+	s.Require().ErrorAs(errs[0], &appErr)
+	s.Equal(ErrTypeDuplicateApproval, appErr.Type())
+}
+
+// The update returns the workflow's resolution status along with the task's.
+func (s *resolveOrderSuite) TestDecisionUpdateReturnsWorkflowStatus() {
+	s.refund.AmountCents = 51000
+	var res modal.DecisionResult
+	s.decideAfter(time.Minute, "review-transfer", false, "carol")
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(TaskDecisionUpdate, "approve", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("decision rejected", err) },
+			OnComplete: func(v any, err error) {
+				s.Require().NoError(err)
+				res = v.(modal.DecisionResult)
+			},
+		}, modal.TaskDecision{TaskID: "task-" + testOrderID + "-refund-buyer", Approved: true, Decider: "alice"})
+	}, 2*time.Minute)
+	s.decideAfter(3*time.Minute, "refund-buyer", true, "bob")
+	s.decideAfter(4*time.Minute, "escalate-supplier", true, "carol")
+	s.execute()
+
+	s.requireResult(modal.OutcomeRefunded)
+	s.Equal(1, res.Approvals)
+	s.Equal(modal.TaskOpen, res.TaskStatus)
+	s.Equal(StatusInProgress, res.WorkflowStatus)
+}
+
+func (s *resolveOrderSuite) TestClaimAndDecisionUpdate() {
+	taskID := "task-" + testOrderID + "-review-transfer"
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(TaskClaimSignal, modal.TaskClaim{TaskID: taskID, Claimant: "alice"})
+	}, time.Minute)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(TaskDecisionUpdate, "unknown", &testsuite.TestUpdateCallback{
+			OnAccept: func() { s.Fail("decision for an unknown task accepted") },
+			OnReject: func(err error) {
+				var appErr *temporal.ApplicationError
+				s.Require().ErrorAs(err, &appErr)
+				s.Equal(ErrTypeUnknownTask, appErr.Type())
+			},
+			OnComplete: func(any, error) {},
+		}, modal.TaskDecision{TaskID: "task-nope", Approved: true, Decider: "alice"})
+
+		s.env.UpdateWorkflow(TaskDecisionUpdate, "approve", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("decision rejected", err) },
+			OnComplete: func(v any, err error) {
+				s.Require().NoError(err)
+				res := v.(modal.DecisionResult)
+				s.Equal(modal.TaskApproved, res.TaskStatus)
+				s.Empty(res.OpenTasks)
+			},
+		}, modal.TaskDecision{TaskID: taskID, Approved: true, Decider: "alice"})
+	}, 2*time.Minute)
+	s.execute()
+
+	s.requireResult(modal.OutcomeEscalatedApproved)
+	tasks := s.tasks()
+	s.Require().Len(tasks, 1)
+	s.Equal("alice", tasks[0].ClaimedBy)
+	s.Contains(s.auditKinds(), "TASK_CLAIMED")
+}
+
+// Versioning.
+
+// Executions started before the GetVersion markers replay every stage as DefaultVersion and must take the same path.
+func (s *resolveOrderSuite) TestExecutionsWithoutVersionMarkers() {
+	asked := make(map[string]bool)
+	for _, st := range stages {
+		s.env.OnGetVersion(st.changeID, st.min, st.max).Return(workflow.DefaultVersion).
+			Run(func(mock.Arguments) { asked[st.changeID] = true })
+	}
+	s.decideAfter(time.Minute, "review-transfer", false, "alice")
+	s.decideAfter(2*time.Minute, "refund-buyer", true, "alice")
+	s.decideAfter(3*time.Minute, "escalate-supplier", true, "bob")
+	s.execute()
+
+	s.requireResult(modal.OutcomeRefunded)
+	for _, st := range stages {
+		if st == stageCompensate || st == stageChild || st == stageWait {
+			continue // only reached when the run fails, or by playbooks with child workflows or waits
+		}
+		s.True(asked[st.changeID], "stage %s was never versioned", st.changeID)
+	}
+}
+
+// Executions that failed before compensation was added replay without compensating.
+func (s *resolveOrderSuite) TestFailuresWithoutCompensateMarkerCompensateNothing() {
+	s.env.OnGetVersion(stageCompensate.changeID, stageCompensate.min, stageCompensate.max).Return(workflow.DefaultVersion)
+	s.env.RegisterDelayedCallback(s.env.CancelWorkflow, time.Minute)
+	s.execute()
+
+	s.True(temporal.IsCanceledError(s.env.GetWorkflowError()))
+	s.Equal([]string{"RETRY_TRANSFER", "RETRY_TRANSFER", "RETRY_TRANSFER", "PING_SUPPLIER"}, actionTypes(s.attempts()))
+	s.NotContains(s.auditKinds(), "COMPENSATING")
+}
+
+// Compensation.
+
+// undoneKeys returns the forward idempotency keys of the compensations in attempts, in the order they ran.
+func undoneKeys(attempts []modal.ActionAttemp) []string {
+	var keys []string
+	for _, a := range attempts {
+		if k, ok := strings.CutSuffix(a.IdempotencyKey, "/undo"); ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// A buyer notification failing after the refund does not fail the run, so the refund stands and nothing is undone.
+func (s *resolveOrderSuite) TestFailedNotifyAfterRefundLeavesTheRefundInPlace() {
+	s.notifyErr = temporal.NewNonRetryableApplicationError("template not found", "NotFound", nil)
+	s.decideAfter(time.Minute, "review-transfer", false, "alice")
+	s.decideAfter(2*time.Minute, "refund-buyer", true, "alice")
+	s.decideAfter(3*time.Minute, "escalate-supplier", true, "bob")
+	s.execute()
+
+	s.requireResult(modal.OutcomeRefunded)
+	attempts := s.attempts()
+	s.Equal([]string{
+		"RETRY_TRANSFER", "RETRY_TRANSFER", "RETRY_TRANSFER", "PING_SUPPLIER", "ISSUE_REFUND", "NOTIFY_BUYER",
+	}, actionTypes(attempts))
+	s.Equal(string(modal.RefundIssued), attempts[4].Result)
+	s.Equal(modal.AttemptFailed, attempts[5].Result)
+	s.NotContains(s.auditKinds(), "COMPENSATING")
+}
+
+// A run that fails after its refund (here, cancelled while the supplier escalation is open) voids the refund first.
+func (s *resolveOrderSuite) TestCancellationAfterRefundVoidsIt() {
+	s.decideAfter(time.Minute, "review-transfer", false, "alice")
+	s.decideAfter(2*time.Minute, "refund-buyer", true, "alice")
+	s.env.RegisterDelayedCallback(s.env.CancelWorkflow, 3*time.Minute)
+	s.execute()
+
+	s.True(temporal.IsCanceledError(s.env.GetWorkflowError()))
+	attempts := s.attempts()
+	s.Equal([]string{
+		testWorkflowID + "/refund-buyer/1",
+		testWorkflowID + "/retry-transfer/3",
+		testWorkflowID + "/retry-transfer/2",
+		testWorkflowID + "/retry-transfer/1",
+	}, undoneKeys(attempts))
+	s.Equal("VOID_REFUND", attempts[5].ActionType)
+	s.Equal(modal.RefundVoided, attempts[5].Result)
+	s.Contains(s.auditKinds(), "COMPENSATED")
+}
+
+// Executions that issued their refund on version 2 of the refund stage never void it.
+func (s *resolveOrderSuite) TestRefundStageV2DoesNotVoidRefund() {
+	s.env.OnGetVersion(stageRefund.changeID, stageRefund.min, stageRefund.max).Return(workflow.Version(2))
+	s.notifyErr = temporal.NewNonRetryableApplicationError("template not found", "NotFound", nil)
+	s.decideAfter(time.Minute, "review-transfer", false, "alice")
+	s.decideAfter(2*time.Minute, "refund-buyer", true, "alice")
+	s.decideAfter(3*time.Minute, "escalate-supplier", true, "bob")
+	s.execute()
+
+	s.Error(s.env.GetWorkflowError())
+	s.Equal([]string{
+		testWorkflowID + "/retry-transfer/3",
+		testWorkflowID + "/retry-transfer/2",
+		testWorkflowID + "/retry-transfer/1",
+	}, undoneKeys(s.attempts()))
+}
+
+// Executions that issued their refund on version 1 of the refund stage still void it, newest first.
+func (s *resolveOrderSuite) TestRefundStageV1VoidsRefundOnFailure() {
+	s.env.OnGetVersion(stageRefund.changeID, stageRefund.min, stageRefund.max).Return(workflow.Version(1))
+	s.notifyErr = temporal.NewNonRetryableApplicationError("template not found", "NotFound", nil)
+	s.decideAfter(time.Minute, "review-transfer", false, "alice")
+	s.decideAfter(2*time.Minute, "refund-buyer", true, "alice")
+	s.decideAfter(3*time.Minute, "escalate-supplier", true, "bob")
+	s.execute()
+
+	s.Error(s.env.GetWorkflowError())
+	attempts := s.attempts()
+	s.Equal([]string{
+		testWorkflowID + "/refund-buyer/1",
+		testWorkflowID + "/retry-transfer/3",
+		testWorkflowID + "/retry-transfer/2",
+		testWorkflowID + "/retry-transfer/1",
+	}, undoneKeys(attempts))
+	s.Equal("VOID_REFUND", attempts[6].ActionType)
+	s.Equal(modal.RefundVoided, attempts[6].Result)
+}
+
+func (s *resolveOrderSuite) TestCancellationCompensates() {
+	s.env.RegisterDelayedCallback(s.env.CancelWorkflow, time.Minute)
+	s.execute()
+
+	s.True(temporal.IsCanceledError(s.env.GetWorkflowError()))
+	s.Equal([]string{
+		testWorkflowID + "/retry-transfer/3",
+		testWorkflowID + "/retry-transfer/2",
+		testWorkflowID + "/retry-transfer/1",
+	}, undoneKeys(s.attempts()))
+}
+
+// A transfer the buyer accepted is delivered, not on offer: there is nothing to cancel.
+func (s *resolveOrderSuite) TestAcceptedTransferIsNotCompensated() {
+	s.retryResults = []modal.TransferStatus{modal.TransferNotAccepted, modal.TransferAccepted}
+	s.execute()
+
+	s.requireResult(modal.OutcomeResolvedAutomatically)
+	s.NotContains(actionTypes(s.attempts()), "CANCEL_TRANSFER")
+}
+
+func (s *resolveOrderSuite) TestFailedCompensationIsAuditedAndTheRestRun() {
+	s.env.OnGetVersion(stageRefund.changeID, stageRefund.min, stageRefund.max).Return(workflow.Version(1))
+	s.notifyErr = temporal.NewNonRetryableApplicationError("template not found", "NotFound", nil)
+	s.voidErr = temporal.NewNonRetryableApplicationError("refund already settled", "Conflict", nil)
+	s.decideAfter(time.Minute, "review-transfer", false, "alice")
+	s.decideAfter(2*time.Minute, "refund-buyer", true, "alice")
+	s.decideAfter(3*time.Minute, "escalate-supplier", true, "bob")
+	s.execute()
+
+	s.Error(s.env.GetWorkflowError())
+	attempts := s.attempts()
+	s.Require().Len(undoneKeys(attempts), 4)
+	s.Equal("VOID_REFUND", attempts[6].ActionType)
+	s.Equal(modal.AttemptFailed, attempts[6].Result)
+	s.Equal(string(modal.TransferCancelled), attempts[len(attempts)-1].Result)
+	s.Contains(s.auditKinds(), "COMPENSATION_FAILED")
+}
+
+// Child workflows.
+
+// replacementPlaybook sources replacement seats in a child workflow, then tells the buyer from another.
+var replacementPlaybook = playbook.Playbook{
+	IssueType: modal.IssueTransferFailed,
+	Version:   1,
+	Steps: []playbook.Step{
+		{ID: "source", Kind: playbook.KindChildWorkflow, Workflow: playbook.ChildSourceReplacement,
+			Params: map[string]string{"searchEvery": "1h", "searchFor": "3h"},
+			On:     map[string]string{playbook.OutcomeNotFound: "not-found"}},
+		{ID: "notify", Kind: playbook.KindChildWorkflow, Workflow: playbook.ChildNotifyBuyer,
+			Params: map[string]string{"template": "replacement_reserved"}},
+		{ID: "reserved", Kind: playbook.KindFinish, Result: string(modal.OutcomeResolvedAutomatically), Message: "replacement reserved"},
+		{ID: "not-found", Kind: playbook.KindFinish, Result: string(modal.OutcomeManualFollowUp), Message: "no replacement found"},
+	},
+}
+
+func (s *resolveOrderSuite) casefile() modal.CaseFile {
+	v, err := s.env.QueryWorkflow("casefile")
+	s.Require().NoError(err)
+	var cf modal.CaseFile
+	s.Require().NoError(v.Get(&cf))
+	return cf
+}
+
+func (s *resolveOrderSuite) TestChildWorkflowsReserveReplacementAndNotifyBuyer() {
+	s.Require().NoError(replacementPlaybook.Validate())
+	s.env.OnActivity("LoadPlaybook", mock.Anything, mock.Anything).Return(replacementPlaybook, nil)
+	s.listings = []modal.SeatListing{
+		{ListingID: "LST-1", PriceCents: 11000, Currency: "USD"},
+		{ListingID: "LST-2", PriceCents: 13000, Currency: "USD"},
+	}
+	s.reserveErrs = map[string]error{"LST-1": temporal.NewNonRetryableApplicationError("already reserved", "Conflict", nil)}
+	s.execute()
+
+	s.requireResult(modal.OutcomeResolvedAutomatically)
+	cf := s.casefile()
+	s.Require().Len(cf.Children, 2)
+	s.Equal(testWorkflowID+"-source", cf.Children[0].WorkflowID)
+	s.Equal(modal.ChildCompleted, cf.Children[0].Status)
+	s.Equal(playbook.OutcomeReserved, cf.Children[0].Outcome)
+	s.Equal(playbook.ChildNotifyBuyer, cf.Children[1].Type)
+	s.Equal(modal.ChildCompleted, cf.Children[1].Status)
+	s.Require().NotNil(cf.Replacement)
+	s.Equal("LST-2", cf.Replacement.Listing.ListingID)
+
+	attempts := s.attempts()
+	s.Equal([]string{"RESERVE_SEATS", "RESERVE_SEATS", "NOTIFY_BUYER"}, actionTypes(attempts))
+	s.Equal(modal.AttemptFailed, attempts[0].Result)
+	s.Equal(testWorkflowID+"-source/reserve-seats/2", attempts[1].IdempotencyKey)
+	s.Contains(s.auditKinds(), "CHILD_COMPLETED")
+}
+
+func (s *resolveOrderSuite) TestSourceReplacementSearchesAgainUntilItGivesUp() {
+	s.env.OnActivity("LoadPlaybook", mock.Anything, mock.Anything).Return(replacementPlaybook, nil)
+	s.execute()
+
+	s.requireResult(modal.OutcomeManualFollowUp)
+	s.Equal(3, s.searches) // at 0h, 1h and 2h of the 3h window
+	cf := s.casefile()
+	s.Require().Len(cf.Children, 1)
+	s.Equal(playbook.OutcomeNotFound, cf.Children[0].Outcome)
+	s.Nil(cf.Replacement)
+}
+
+// Seats a child reserved are released when the parent fails afterwards.
+func (s *resolveOrderSuite) TestFailedParentReleasesReplacementSeats() {
+	s.env.OnActivity("LoadPlaybook", mock.Anything, mock.Anything).Return(replacementPlaybook, nil)
+	s.listings = []modal.SeatListing{{ListingID: "LST-1", PriceCents: 11000, Currency: "USD"}}
+	s.notifyErr = temporal.NewNonRetryableApplicationError("template not found", "NotFound", nil)
+	s.execute()
+
+	s.Error(s.env.GetWorkflowError())
+	cf := s.casefile()
+	s.Require().Len(cf.Children, 2)
+	s.Equal(modal.ChildFailed, cf.Children[1].Status)
+	attempts := s.attempts()
+	s.Equal([]string{testWorkflowID + "-source/reserve-seats/1"}, undoneKeys(attempts))
+	s.Equal(modal.ReservationReleased, attempts[len(attempts)-1].Result)
+}
+
+// Supplier cannot fulfill.
+
+func (s *resolveOrderSuite) supplierCannotFulfill(listings ...modal.SeatListing) {
+	s.comms = []modal.SupplierMessage{{Direction: "INBOUND", Body: "Sorry, we cannot fulfill this order: the seats were double sold."}}
+	s.listings = listings
+}
+
+func (s *resolveOrderSuite) TestSupplierCannotFulfillBuysAndTransfersReplacement() {
+	s.supplierCannotFulfill(modal.SeatListing{ListingID: "LST-1", PriceCents: 12500, Currency: "USD"})
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeResolvedAutomatically)
+	s.Equal(modal.DecidedByAutomation, res.DecidedBy)
+	s.Equal(modal.PriceBand{MinCents: 9000, MaxCents: 15000}, s.band)
+	s.Equal([]string{"RESERVE_SEATS", "PURCHASE_SEATS", "TRANSFER_REPLACEMENT", "NOTIFY_BUYER"}, actionTypes(s.attempts()))
+	cf := s.casefile()
+	s.Equal(modal.IssueSupplierCannotFulfill, cf.IssueType)
+	s.Require().NotNil(cf.Replacement)
+	s.Equal(modal.ReservationPurchased, cf.Replacement.Status)
+	s.Equal(modal.TransferAccepted, cf.TransferStatus)
+}
+
+func (s *resolveOrderSuite) TestReplacementAbovePricePolicyNeedsApproval() {
+	s.supplierCannotFulfill(modal.SeatListing{ListingID: "LST-1", PriceCents: 14000, Currency: "USD"})
+	s.decideAfter(time.Minute, "approve-replacement", true, "alice")
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeEscalatedApproved)
+	s.Equal("alice", res.DecidedBy)
+	s.Equal([]string{"REPLACEMENT_APPROVAL"}, taskTypes(s.tasks()))
+	s.Equal([]string{"RESERVE_SEATS", "PURCHASE_SEATS", "TRANSFER_REPLACEMENT", "NOTIFY_BUYER"}, actionTypes(s.attempts()))
+}
+
+// A rejected replacement is released once, by the playbook, and not again by the saga if the run fails later.
+func (s *resolveOrderSuite) TestRejectedReplacementIsReleasedAndRefunded() {
+	s.supplierCannotFulfill(modal.SeatListing{ListingID: "LST-1", PriceCents: 14000, Currency: "USD"})
+	s.decideAfter(time.Minute, "approve-replacement", false, "alice")
+	s.decideAfter(2*time.Minute, "refund-buyer", true, "bob")
+	s.notifyErr = temporal.NewNonRetryableApplicationError("template not found", "NotFound", nil)
+	s.execute()
+
+	s.requireResult(modal.OutcomeRefunded)
+	attempts := s.attempts()
+	s.Equal([]string{"RESERVE_SEATS", "RELEASE_SEATS", "ISSUE_REFUND", "NOTIFY_BUYER"}, actionTypes(attempts))
+	s.Empty(undoneKeys(attempts), "a failed refund notification leaves the refund in place")
+	s.Equal(modal.ReservationReleased, s.casefile().Replacement.Status)
+}
+
+// Seat mismatch and partial fulfillment playbooks.
+
+func (s *resolveOrderSuite) TestSeatMismatchCorrectedBySupplier() {
+	s.transfer = modal.TransferContext{Status: modal.TransferAccepted, DeliveredSeats: []modal.Seat{
+		testSeats[0], testSeats[1], {Section: "305", Row: "Z", Number: "3"},
+	}}
+	s.corrections = []string{modal.CorrectionDeclined, modal.CorrectionCorrected}
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeResolvedAutomatically)
+	s.Equal("supplier transferred the seats sold in place of the wrong ones", res.Reason)
+	s.Equal([]string{"REQUEST_CORRECTION", "REQUEST_CORRECTION", "NOTIFY_BUYER"}, actionTypes(s.attempts()))
+	s.Empty(s.tasks())
+	cf := s.casefile()
+	s.Equal(modal.IssueSeatMismatch, cf.IssueType)
+	s.Equal(testSeats, cf.DeliveredSeats)
+	s.Require().NotNil(cf.Discrepancy)
+	s.Empty(cf.Discrepancy.Missing)
+}
+
+func (s *resolveOrderSuite) TestPartialFulfillmentRefundsMissingSeats() {
+	s.transfer = modal.TransferContext{Status: modal.TransferAccepted, DeliveredSeats: testSeats[:2]}
+	s.decideAfter(9*time.Hour, "refund-seats", true, "alice")
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeRefunded)
+	s.Equal("alice", res.DecidedBy)
+	s.Equal([]string{"REQUEST_CORRECTION", "REQUEST_CORRECTION", "REQUEST_CORRECTION", "ISSUE_REFUND", "NOTIFY_BUYER"},
+		actionTypes(s.attempts()))
+	tasks := s.tasks()
+	s.Require().Len(tasks, 1)
+	// One of three seats is missing, so a third of the order amount is refunded.
+	s.Equal(int64(4000), tasks[0].Refund.AmountCents)
+	s.Contains(tasks[0].Refund.Rationale, "1 of 3 seats not delivered as sold")
+	cf := s.casefile()
+	s.Equal(modal.IssuePartialFulfillment, cf.IssueType)
+	s.Require().NotNil(cf.Discrepancy)
+	s.Equal([]modal.Seat{testSeats[2]}, cf.Discrepancy.Missing)
+}
+
+// Transfer blocked playbook.
+
+func (s *resolveOrderSuite) TestBlockedTransferRetriedAfterUnlock() {
+	unlock := s.env.Now().Add(72 * time.Hour)
+	s.transfer.BlockedUntil = &unlock
+	s.retryResults = []modal.TransferStatus{modal.TransferAccepted}
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeResolvedAutomatically)
+	s.Equal("transfer accepted once the venue unlocked transfers", res.Reason)
+	attempts := s.attempts()
+	s.Equal([]string{"NOTIFY_BUYER", "RETRY_TRANSFER"}, actionTypes(attempts))
+	// The buyer is told right away; the transfer is only retried once the venue allows it.
+	s.True(attempts[0].AttemptedAt.Before(unlock))
+	s.False(attempts[1].AttemptedAt.Before(unlock))
+	s.Subset(s.auditKinds(), []string{"WAIT_STARTED", "WAIT_ELAPSED"})
+	s.Equal(modal.IssueTransferBlocked, s.casefile().IssueType)
+}
+
+func (s *resolveOrderSuite) TestBlockedTransferStillFailingAfterUnlockEscalates() {
+	unlock := s.env.Now().Add(48 * time.Hour)
+	s.transfer.BlockedUntil = &unlock
+	s.decideAfter(50*time.Hour, "review-transfer", true, "alice")
+	s.execute()
+
+	res := s.requireResult(modal.OutcomeEscalatedApproved)
+	s.Equal("alice", res.DecidedBy)
+	s.Equal([]string{"NOTIFY_BUYER", "RETRY_TRANSFER", "RETRY_TRANSFER", "RETRY_TRANSFER"}, actionTypes(s.attempts()))
+	s.Equal([]string{modal.TaskRetryTransfer}, taskTypes(s.tasks()))
+}
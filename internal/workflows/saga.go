@@ -0,0 +1,102 @@
+package workflows
+
+import (
+	"broken-order-service/internal/modal"
+	"slices"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// A playbook run can fail, or be cancelled, after some of its side effects went through: a transfer re-sent,
+// a refund issued. As each one succeeds, the saga records the activity that undoes it; when the run ends in an
+// error, ResolveBrokenOrder runs them newest first. Compensation is best effort: one that still fails after its
+// retries is audited for ops to undo by hand, and the rest run anyway. Side effects that cannot be taken back
+// (buyer notifications, supplier pings, reauthorizations) register nothing.
+
+// compensationActivityOptions retries compensations for longer than forward actions: giving up leaves the order
+// half remediated. Non-retryable adapter errors (4xx) still fail at once.
+var compensationActivityOptions = workflow.ActivityOptions{
+	StartToCloseTimeout: 10 * time.Second,
+	RetryPolicy: &temporal.RetryPolicy{
+		InitialInterval:    1 * time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    1 * time.Minute,
+		MaximumAttempts:    10,
+	},
+}
+
+// compensation undoes one side effect recorded in the ledger.
+type compensation struct {
+	// actionType is the ledger action type of the compensating attempt, e.g. CANCEL_TRANSFER.
+	actionType string
+	// activity is executed with the compensating ledger entry followed by args.
+	activity string
+	args     []any
+	// result is recorded in the ledger when the activity succeeds.
+	result string
+	// forward is the ledger entry of the side effect being undone.
+	forward modal.ActionAttemp
+}
+
+// saga is the stack of compensations registered during a run.
+type saga struct {
+	compensations []compensation
+}
+
+// add registers c to run if the workflow fails after this point.
+func (s *saga) add(c compensation) {
+	s.compensations = append(s.compensations, c)
+}
+
+// registered reports whether a compensation of actionType is waiting to run.
+func (s *saga) registered(actionType string) bool {
+	return slices.ContainsFunc(s.compensations, func(c compensation) bool { return c.actionType == actionType })
+}
+
+// drop unregisters the compensations of the side effect made by the ledger entry with key forwardKey, once a
+// later step has consumed or undone it.
+func (s *saga) drop(forwardKey string) {
+	s.compensations = slices.DeleteFunc(s.compensations, func(c compensation) bool {
+		return c.forward.IdempotencyKey == forwardKey
+	})
+}
+
+// compensate runs the registered compensations in reverse order, recording each in the ledger and the audit log,
+// and clears them. ctx must not be cancelled (ResolveBrokenOrder passes a disconnected context), so a cancelled
+// workflow is still compensated.
+func (r *runner) compensate(ctx workflow.Context, cause error) {
+	cs := r.saga.compensations
+	if len(cs) == 0 || !stageCompensate.since(ctx, 1) {
+		return
+	}
+	r.saga.compensations = nil
+	ctx = workflow.WithActivityOptions(ctx, compensationActivityOptions)
+
+	r.audit("COMPENSATING", "undoing side effects after the workflow failed", map[string]any{
+		"cause":         cause.Error(),
+		"compensations": len(cs),
+	})
+	for k := len(cs) - 1; k >= 0; k-- {
+		c := cs[k]
+		i, req := r.beginCompensation(ctx, c)
+		args := append([]any{req}, c.args...)
+		if err := workflow.ExecuteActivity(ctx, c.activity, args...).Get(ctx, nil); err != nil {
+			r.endAttempt(i, modal.AttemptFailed)
+			r.audit("COMPENSATION_FAILED", c.activity+" failed; the side effect must be undone by hand", map[string]any{
+				"action":         c.actionType,
+				"for":            c.forward.IdempotencyKey,
+				"idempotencyKey": req.IdempotencyKey,
+				"error":          err.Error(),
+			})
+			continue
+		}
+		r.endAttempt(i, c.result)
+		r.audit("COMPENSATED", "side effect undone", map[string]any{
+			"action":         c.actionType,
+			"for":            c.forward.IdempotencyKey,
+			"idempotencyKey": req.IdempotencyKey,
+		})
+	}
+}
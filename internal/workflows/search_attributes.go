@@ -0,0 +1,123 @@
+package workflows
+
+import (
+	"broken-order-service/internal/modal"
+	"slices"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Custom search attributes. The workflow keeps them current so the ops UI can list and filter executions
+// with a single visibility query instead of querying every workflow.
+var (
+	// OrderIDKey correlates executions with the order they resolve. Workflow IDs are per event, so an
+	// order reported more than once has several executions sharing this value.
+	OrderIDKey = temporal.NewSearchAttributeKeyKeyword("OrderId")
+	// IssueTypeKey is the case file's issue type once the case file is built.
+	IssueTypeKey = temporal.NewSearchAttributeKeyKeyword("IssueType")
+	// ResolutionStatusKey is IN_PROGRESS while the workflow runs, then its outcome (or FAILED).
+	ResolutionStatusKey = temporal.NewSearchAttributeKeyKeyword("ResolutionStatus")
+	// HasPendingTaskKey is true while any human task awaits a decision.
+	HasPendingTaskKey = temporal.NewSearchAttributeKeyBool("HasPendingTask")
+	// TierKey is the highest support tier among the pending tasks; unset when there are none.
+	TierKey = temporal.NewSearchAttributeKeyKeyword("Tier")
+	// AssignedToKey lists who has claimed the pending tasks.
+	AssignedToKey = temporal.NewSearchAttributeKeyKeywordList("AssignedTo")
+)
+
+// SearchAttributes lists the custom search attributes the workflows set, by name and type.
+// They must be registered on the namespace before a workflow uses them (go run ./cmd/searchattrs).
+var SearchAttributes = map[string]enums.IndexedValueType{
+	OrderIDKey.GetName():          enums.INDEXED_VALUE_TYPE_KEYWORD,
+	IssueTypeKey.GetName():        enums.INDEXED_VALUE_TYPE_KEYWORD,
+	ResolutionStatusKey.GetName(): enums.INDEXED_VALUE_TYPE_KEYWORD,
+	HasPendingTaskKey.GetName():   enums.INDEXED_VALUE_TYPE_BOOL,
+	TierKey.GetName():             enums.INDEXED_VALUE_TYPE_KEYWORD,
+	AssignedToKey.GetName():       enums.INDEXED_VALUE_TYPE_KEYWORD_LIST,
+}
+
+// ResolutionStatus values other than a modal.Outcome.
+const (
+	StatusInProgress = "IN_PROGRESS"
+	StatusFailed     = "FAILED"
+)
+
+// indexedState is the last set of values written to the search attributes.
+type indexedState struct {
+	issueType  modal.IssueType
+	status     string
+	pending    bool
+	tier       string
+	assignedTo []string
+}
+
+// indexedNow derives the search attribute values from the workflow state.
+func (r *runner) indexedNow() indexedState {
+	s := indexedState{
+		issueType: r.state.CaseFile.IssueType,
+		status:    r.status,
+	}
+	for _, t := range r.state.taskList() {
+		if !t.Status.Active() {
+			continue
+		}
+		s.pending = true
+		if t.Tier > s.tier { // TIER1 < TIER2 < ...
+			s.tier = t.Tier
+		}
+		if t.ClaimedBy != "" && !slices.Contains(s.assignedTo, t.ClaimedBy) {
+			s.assignedTo = append(s.assignedTo, t.ClaimedBy)
+		}
+	}
+	return s
+}
+
+// setStatus records the resolution status and indexes it.
+func (r *runner) setStatus(ctx workflow.Context, status string) {
+	r.status = status
+	r.upsertSearchAttributes(ctx)
+}
+
+// upsertSearchAttributes writes the attributes whose values changed since the last call. It is called
+// after every change to the case file issue type, the status or a task, and only writes what changed, so
+// each stage adds at most one upsert to history.
+func (r *runner) upsertSearchAttributes(ctx workflow.Context) {
+	now := r.indexedNow()
+	prev := r.indexed
+	var updates []temporal.SearchAttributeUpdate
+
+	if now.issueType != prev.issueType && now.issueType != "" {
+		updates = append(updates, IssueTypeKey.ValueSet(string(now.issueType)))
+	}
+	if now.status != prev.status && now.status != "" {
+		updates = append(updates, ResolutionStatusKey.ValueSet(now.status))
+	}
+	if now.pending != prev.pending || prev.status == "" {
+		updates = append(updates, HasPendingTaskKey.ValueSet(now.pending))
+	}
+	if now.tier != prev.tier {
+		if now.tier == "" {
+			updates = append(updates, TierKey.ValueUnset())
+		} else {
+			updates = append(updates, TierKey.ValueSet(now.tier))
+		}
+	}
+	if !slices.Equal(now.assignedTo, prev.assignedTo) {
+		if len(now.assignedTo) == 0 {
+			updates = append(updates, AssignedToKey.ValueUnset())
+		} else {
+			updates = append(updates, AssignedToKey.ValueSet(now.assignedTo))
+		}
+	}
+	if len(updates) == 0 {
+		return
+	}
+
+	if err := workflow.UpsertTypedSearchAttributes(ctx, updates...); err != nil {
+		workflow.GetLogger(ctx).Error("failed to upsert search attributes", "error", err)
+		return
+	}
+	r.indexed = now
+}
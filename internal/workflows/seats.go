@@ -0,0 +1,68 @@
+package workflows
+
+import (
+	"broken-order-service/internal/modal"
+	"broken-order-service/internal/playbook"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// The seat actions handle deliveries that do not match the order: the buyer got the wrong seats, or only some of
+// them. CompareSeats works out the discrepancy from the case file; RequestSeatCorrection asks the supplier to fix
+// it. A refund step with basis "seats" then refunds whatever is still missing (see refund.go).
+
+// actionCompareSeats records the discrepancy between the seats sold and the seats delivered. It is a pure
+// function of the case file, so it needs no activity and writes no ledger entry.
+func actionCompareSeats(ctx workflow.Context, r *runner, step playbook.Step, attempt int) (string, error) {
+	cf := &r.state.CaseFile
+	d := cf.CompareSeats()
+	cf.Discrepancy = &d
+
+	outcome := playbook.OutcomeMatch
+	switch d.IssueType() {
+	case modal.IssueSeatMismatch:
+		outcome = playbook.OutcomeMismatch
+	case modal.IssuePartialFulfillment:
+		outcome = playbook.OutcomePartial
+	}
+	r.audit("SEATS_COMPARED", "delivered seats compared with the order", map[string]any{
+		"missing":    len(d.Missing),
+		"unexpected": len(d.Unexpected),
+		"valueCents": d.ValueCents,
+		"currency":   d.Currency,
+		"outcome":    outcome,
+	})
+	return outcome, nil
+}
+
+// actionRequestSeatCorrection asks the supplier to deliver the seats sold. Once the supplier corrects the
+// delivery the buyer holds every seat sold, so nothing is left to refund.
+func actionRequestSeatCorrection(ctx workflow.Context, r *runner, step playbook.Step, attempt int) (string, error) {
+	cf := &r.state.CaseFile
+	d := cf.CompareSeats()
+	i, req := r.beginAttempt(ctx, "REQUEST_CORRECTION", step, attempt)
+	var status string
+	if err := workflow.ExecuteActivity(ctx, "RequestSeatCorrection", req, d).Get(ctx, &status); err != nil {
+		r.endAttempt(i, modal.AttemptFailed)
+		r.audit("ERROR", "RequestSeatCorrection failed", map[string]any{
+			"idempotencyKey": req.IdempotencyKey,
+			"error":          err.Error(),
+		})
+		return "", err
+	}
+	r.endAttempt(i, status)
+
+	r.audit("CORRECTION_REQUESTED", "supplier asked to correct the delivered seats", map[string]any{
+		"missing":        len(d.Missing),
+		"unexpected":     len(d.Unexpected),
+		"idempotencyKey": req.IdempotencyKey,
+		"status":         status,
+	})
+	if status == modal.CorrectionCorrected {
+		cf.DeliveredSeats = append([]modal.Seat(nil), cf.ExpectedSeats...)
+		cf.TransferStatus = modal.TransferAccepted
+		d = cf.CompareSeats()
+		cf.Discrepancy = &d
+	}
+	return status, nil
+}
@@ -0,0 +1,55 @@
+package workflows
+
+import (
+	"broken-order-service/internal/modal"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// armSLA adds the SLA timers for task to selector: a reminder at half the target, escalation to the
+// next tier at the target, and the hard deadline, which sets *deadlinePassed. Task types without an SLA
+// get no timers. The returned func cancels the timers once the task is closed.
+func (r *runner) armSLA(ctx workflow.Context, selector workflow.Selector, task *modal.HumanTask, deadlinePassed *bool) workflow.CancelFunc {
+	tctx, cancel := workflow.WithCancel(ctx)
+	sla, ok := r.slas[task.Type]
+	if !ok {
+		return cancel
+	}
+
+	target := sla.TargetDuration()
+	task.DueAt = task.CreatedAt.Add(target)
+	after := func(d time.Duration, fn func()) {
+		selector.AddFuture(workflow.NewTimer(tctx, d), func(f workflow.Future) {
+			if f.Get(tctx, nil) == nil { // nil unless the timer was cancelled
+				fn()
+			}
+		})
+	}
+
+	after(target/2, func() {
+		r.audit("TASK_REMINDER", "human task is halfway to its SLA target", map[string]any{
+			"taskId": task.ID,
+			"tier":   task.Tier,
+			"dueAt":  task.DueAt,
+		})
+	})
+	after(target, func() {
+		from := task.Tier
+		task.Tier = sla.EscalateTo
+		if task.Tier == "" {
+			task.Tier = modal.Tier2
+		}
+		r.audit("TASK_ESCALATED", "human task missed its SLA target and was escalated", map[string]any{
+			"taskId": task.ID,
+			"from":   from,
+			"to":     task.Tier,
+		})
+		r.upsertSearchAttributes(ctx)
+	})
+	if d := sla.HardDeadlineDuration(); d > 0 {
+		task.DeadlineAt = task.CreatedAt.Add(d)
+		after(d, func() { *deadlinePassed = true })
+	}
+	return cancel
+}
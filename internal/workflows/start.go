@@ -0,0 +1,22 @@
+package workflows
+
+import (
+	"broken-order-service/internal/modal"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+)
+
+// StartOptions returns the options every producer (API, ingest, starter) uses to start ResolveBrokenOrder
+// for ev. The workflow ID comes from the event ID, and starting the same event twice fails with
+// WorkflowExecutionAlreadyStarted instead of running a second workflow.
+func StartOptions(ev modal.BrokenOrderEvent) client.StartWorkflowOptions {
+	return client.StartWorkflowOptions{
+		ID:                                       ev.WorkflowID(),
+		TaskQueue:                                TaskQueue,
+		WorkflowExecutionErrorWhenAlreadyStarted: true,
+		WorkflowIDReusePolicy:                    enums.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE,
+		TypedSearchAttributes:                    temporal.NewSearchAttributes(OrderIDKey.ValueSet(ev.OrderID)),
+	}
+}
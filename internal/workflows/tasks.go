@@ -0,0 +1,123 @@
+package workflows
+
+import (
+	"broken-order-service/internal/modal"
+	"fmt"
+	"sort"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// taskInboxSize bounds how many routed decisions can queue up for one task.
+const taskInboxSize = 16
+
+// taskList returns every task, oldest first. Map order is random, so queries must not use it directly.
+func (s *workflowState) taskList() []modal.HumanTask {
+	list := make([]modal.HumanTask, 0, len(s.Tasks))
+	for _, t := range s.Tasks {
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.Before(list[j].CreatedAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// pendingTask returns the oldest task still awaiting a decision, or the zero task.
+func (s *workflowState) pendingTask() modal.HumanTask {
+	for _, t := range s.taskList() {
+		if t.Status.Active() {
+			return t
+		}
+	}
+	return modal.HumanTask{}
+}
+
+// taskID names the task a step opens. A step re-entered through a playbook cycle opens a new task.
+func (r *runner) taskID(stepID string) string {
+	if v := r.visits[stepID]; v > 1 {
+		return fmt.Sprintf("task-%s-%s-%d", r.orderID, stepID, v)
+	}
+	return fmt.Sprintf("task-%s-%s", r.orderID, stepID)
+}
+
+// routeTaskSignals starts the goroutine that delivers decision and claim signals to tasks by TaskID.
+// Decisions for unknown or closed tasks are dropped and audited.
+func (r *runner) routeTaskSignals(ctx workflow.Context) {
+	r.inbox = make(map[string]workflow.Channel)
+	decisions := workflow.GetSignalChannel(ctx, TaskDecisionSignal)
+	claims := workflow.GetSignalChannel(ctx, TaskClaimSignal)
+
+	workflow.Go(ctx, func(ctx workflow.Context) {
+		selector := workflow.NewSelector(ctx)
+		selector.AddReceive(decisions, func(c workflow.ReceiveChannel, more bool) {
+			var d modal.TaskDecision
+			c.Receive(ctx, &d)
+			inbox, ok := r.inbox[d.TaskID]
+			if !ok {
+				r.audit("DECISION_IGNORED", "decision for unknown or closed task ignored", map[string]any{
+					"taskId":  d.TaskID,
+					"decider": d.Decider,
+				})
+				return
+			}
+			inbox.Send(ctx, d)
+		})
+		selector.AddReceive(claims, func(c workflow.ReceiveChannel, more bool) {
+			var claim modal.TaskClaim
+			c.Receive(ctx, &claim)
+			r.claimTask(ctx, claim)
+		})
+		for {
+			selector.Select(ctx)
+		}
+	})
+}
+
+// openTask registers task as OPEN and returns the channel its decisions are routed to.
+func (r *runner) openTask(ctx workflow.Context, task *modal.HumanTask) workflow.ReceiveChannel {
+	task.Status = modal.TaskOpen
+	r.state.Tasks[task.ID] = task
+	inbox := workflow.NewBufferedChannel(ctx, taskInboxSize)
+	r.inbox[task.ID] = inbox
+
+	r.audit("HUMAN_TASK_CREATED", "created human task for manual review", map[string]any{
+		"taskId":            task.ID,
+		"type":              task.Type,
+		"requiredApprovals": task.RequiredApprovals,
+		"tier":              task.Tier,
+	})
+	workflow.GetLogger(ctx).Info("human task created", "orderID", r.orderID, "taskID", task.ID)
+	r.upsertSearchAttributes(ctx)
+	return inbox
+}
+
+// closeTask records the final status of task and who decided it, and stops routing decisions to it.
+func (r *runner) closeTask(ctx workflow.Context, task *modal.HumanTask, status modal.TaskStatus, decidedBy string) {
+	task.Status = status
+	task.DecidedBy = decidedBy
+	task.ClosedAt = workflow.Now(ctx)
+	delete(r.inbox, task.ID)
+	r.upsertSearchAttributes(ctx)
+}
+
+func (r *runner) claimTask(ctx workflow.Context, claim modal.TaskClaim) {
+	task, ok := r.state.Tasks[claim.TaskID]
+	if !ok || !task.Status.Active() {
+		r.audit("CLAIM_IGNORED", "claim for unknown or closed task ignored", map[string]any{
+			"taskId":   claim.TaskID,
+			"claimant": claim.Claimant,
+		})
+		return
+	}
+	task.Status = modal.TaskClaimed
+	task.ClaimedBy = claim.Claimant
+	r.audit("TASK_CLAIMED", "human task claimed", map[string]any{
+		"taskId":   task.ID,
+		"claimant": claim.Claimant,
+	})
+	r.upsertSearchAttributes(ctx)
+}
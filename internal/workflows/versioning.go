@@ -0,0 +1,72 @@
+package workflows
+
+import "go.temporal.io/sdk/workflow"
+
+// Executions can wait days on a human task, and every worker deploy replays them against the new code. A change to
+// the commands ResolveBrokenOrder issues (which activities, timers and tasks, and in what order) breaks that replay
+// with a non-determinism error unless it is gated with workflow.GetVersion. Editing a playbook needs no gate: the
+// playbook is loaded through an activity, so running executions keep the one recorded in their history.
+//
+// Each stage of the workflow is gated by its own change ID below, marked when the stage is entered. To change a stage:
+//
+//  1. Raise its max to the next version and note what the version changed.
+//  2. Branch on s.since(ctx, max): the new behaviour when true, the existing code otherwise. Executions that entered
+//     the stage before the deploy replay the version they recorded (DefaultVersion for ones that predate the
+//     markers) and keep the old path.
+//  3. Export a history on the new version (cmd/exporthistories) next to the existing ones.
+//  4. Once no running execution has an older version (Temporal records each as "<changeID>-<version>" in the
+//     TemporalChangeVersion search attribute), raise min to max and delete the old branch.
+//
+// For example, capping every action at five attempts whatever its playbook says:
+//
+//	stageAction = stage{"action-retry", workflow.DefaultVersion, 2} // 2: actions capped at 5 attempts
+//
+//	if stageAction.since(ctx, 2) && attempt >= 5 {
+//		return outcome, nil
+//	}
+//
+// A new step is added the same way: a new stage at {DefaultVersion, 1} whose code only runs when since(ctx, 1).
+var (
+	stageGatherCaseFile = stage{"gather-case-file", workflow.DefaultVersion, 1}
+	stageClassify       = stage{"classify-issue", workflow.DefaultVersion, 2} // 2: TRIAGE tasks have an SLA
+	stageLoadPlaybook   = stage{"load-playbook", workflow.DefaultVersion, 1}
+	stageRunPlaybook    = stage{"run-playbook", workflow.DefaultVersion, 1}
+	stageAction         = stage{"action-retry", workflow.DefaultVersion, 1}
+	stageHumanTask      = stage{"human-task", workflow.DefaultVersion, 1}
+	stageRefund         = stage{"refund", workflow.DefaultVersion, 3} // 2: refunds not voided; 3: voided, notices may fail
+	stageParallel       = stage{"parallel", workflow.DefaultVersion, 1}
+	// stageCompensate was added after the markers: executions that failed before it compensate nothing.
+	stageCompensate = stage{"compensate", workflow.DefaultVersion, 1}
+	stageChild      = stage{"child-workflow", workflow.DefaultVersion, 1}
+	stageWait       = stage{"wait", workflow.DefaultVersion, 1}
+)
+
+// stages lists every stage, so tests can check their change IDs and replay them all at one version.
+var stages = []stage{
+	stageGatherCaseFile, stageClassify, stageLoadPlaybook, stageRunPlaybook,
+	stageAction, stageHumanTask, stageRefund, stageParallel, stageCompensate, stageChild, stageWait,
+}
+
+// stage is a part of ResolveBrokenOrder versioned with one workflow.GetVersion change ID.
+// Version 1 of every stage is the behaviour the markers were introduced with.
+type stage struct {
+	changeID string
+	// min is the oldest version still replayed; max is the version new executions record.
+	min, max workflow.Version
+}
+
+// version returns the version of s this execution runs. New executions record s.max the first time it is called.
+func (s stage) version(ctx workflow.Context) workflow.Version {
+	return workflow.GetVersion(ctx, s.changeID, s.min, s.max)
+}
+
+// mark pins the stage version when the stage is entered, so an execution in the middle of a stage during a deploy
+// finishes that stage on the version it started with.
+func (s stage) mark(ctx workflow.Context) {
+	s.version(ctx)
+}
+
+// since reports whether this execution runs version v of s or later.
+func (s stage) since(ctx workflow.Context, v workflow.Version) bool {
+	return s.version(ctx) >= v
+}
@@ -0,0 +1,16 @@
+package workflows
+
+import "testing"
+
+func TestStageChangeIDsAreUnique(t *testing.T) {
+	seen := make(map[string]bool, len(stages))
+	for _, s := range stages {
+		if seen[s.changeID] {
+			t.Errorf("change ID %q is used by more than one stage", s.changeID)
+		}
+		seen[s.changeID] = true
+		if s.min > s.max {
+			t.Errorf("stage %q: min version %d is above max %d", s.changeID, s.min, s.max)
+		}
+	}
+}
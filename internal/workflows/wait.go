@@ -0,0 +1,69 @@
+package workflows
+
+import (
+	"broken-order-service/internal/modal"
+	"broken-order-service/internal/playbook"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// waitTime returns the case file time a wait step names, or nil if it is unset.
+func (r *runner) waitTime(field string) *time.Time {
+	switch field {
+	case "transferBlockedUntil":
+		return r.state.CaseFile.TransferBlockedUntil
+	}
+	return nil
+}
+
+// runWait sleeps until the time the step names. The timer is durable: a worker restart or deploy during the wait
+// resumes it, so a wait of days costs nothing while it runs.
+func (r *runner) runWait(ctx workflow.Context, step playbook.Step) (string, error) {
+	stageWait.mark(ctx)
+	until := r.waitTime(step.Until)
+	if until == nil || !until.After(workflow.Now(ctx)) {
+		r.audit("WAIT_SKIPPED", "nothing to wait for", map[string]any{"step": step.ID, "until": step.Until})
+		return playbook.OutcomeElapsed, nil
+	}
+	r.audit("WAIT_STARTED", "waiting on a durable timer", map[string]any{
+		"step":  step.ID,
+		"until": step.Until,
+		"at":    *until,
+	})
+	if err := workflow.Sleep(ctx, until.Sub(workflow.Now(ctx))); err != nil {
+		return "", err
+	}
+	r.audit("WAIT_ELAPSED", "wait elapsed", map[string]any{"step": step.ID, "until": step.Until})
+	return playbook.OutcomeElapsed, nil
+}
+
+// actionNotifyExpectedDelivery tells the buyer the tickets arrive once the venue unlocks transfers.
+func actionNotifyExpectedDelivery(ctx workflow.Context, r *runner, step playbook.Step, attempt int) (string, error) {
+	expected := r.state.CaseFile.TransferBlockedUntil
+	if expected == nil {
+		return "", temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("step %q: the case file has no expected delivery time", step.ID), playbookErrorType, nil)
+	}
+	template := step.Params["template"]
+	i, req := r.beginAttempt(ctx, "NOTIFY_BUYER", step, attempt)
+	if err := workflow.ExecuteActivity(ctx, "NotifyExpectedDelivery", req, template, *expected).Get(ctx, nil); err != nil {
+		r.endAttempt(i, modal.AttemptFailed)
+		r.audit("ERROR", "NotifyExpectedDelivery failed", map[string]any{
+			"template":       template,
+			"idempotencyKey": req.IdempotencyKey,
+			"error":          err.Error(),
+		})
+		return "", err
+	}
+	r.endAttempt(i, "SENT")
+
+	r.audit("BUYER_NOTIFIED", "buyer told when to expect the tickets", map[string]any{
+		"template":         template,
+		"expectedDelivery": *expected,
+		"idempotencyKey":   req.IdempotencyKey,
+	})
+	return "SENT", nil
+}